@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/egoavara/codex-market/internal/backup"
+	"github.com/egoavara/codex-market/internal/i18n"
+	"github.com/spf13/cobra"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "List and restore backups of modified config files",
+	Long: `Before changing a file it doesn't own (~/.codex/config.toml, Claude's
+settings.json, shell config), codex-market saves a backup of it. The newest
+10 backups of each file are kept in ~/.config/codex-market/backups/.
+
+Example:
+  codex-market backup list
+  codex-market backup list ~/.codex/config.toml
+  codex-market backup restore ~/.codex/config.toml
+  codex-market backup restore ~/.codex/config.toml 20261016-100512.123`,
+}
+
+var backupListCmd = &cobra.Command{
+	Use:   "list [file]",
+	Short: "List backups",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runBackupList,
+}
+
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore <file> [backup-id]",
+	Short: "Restore a file from a backup (default: the newest)",
+	Long: `Restore a file from one of its backups. Without a backup ID the newest
+backup is restored. The contents being replaced are backed up first, so a
+restore can be undone by restoring again.`,
+	Args:        cobra.RangeArgs(1, 2),
+	Annotations: lockState,
+	RunE:        runBackupRestore,
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	backupCmd.AddCommand(backupListCmd)
+	backupCmd.AddCommand(backupRestoreCmd)
+}
+
+func runBackupList(cmd *cobra.Command, args []string) error {
+	files := args
+	if len(files) == 0 {
+		var err error
+		if files, err = backup.Files(); err != nil {
+			return err
+		}
+	}
+	if len(files) == 0 {
+		fmt.Println(i18n.T("NoBackups", nil))
+		return nil
+	}
+
+	for i, file := range files {
+		path, err := backupTargetPath(file)
+		if err != nil {
+			return err
+		}
+		backups, err := backup.List(path)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println(path)
+		if len(backups) == 0 {
+			fmt.Println("  (no backups)")
+		}
+		for _, b := range backups {
+			fmt.Printf("  %-24s %s  %d bytes\n", b.ID, b.Created.Local().Format("2006-01-02 15:04:05"), b.Size)
+		}
+	}
+	return nil
+}
+
+func runBackupRestore(cmd *cobra.Command, args []string) error {
+	path, err := backupTargetPath(args[0])
+	if err != nil {
+		return err
+	}
+	id := ""
+	if len(args) > 1 {
+		id = args[1]
+	}
+
+	restored, err := backup.Restore(path, id)
+	if err != nil {
+		return err
+	}
+	fmt.Println(i18n.T("BackupRestored", map[string]any{"Path": path, "ID": restored.ID}))
+	return nil
+}
+
+// backupTargetPath resolves a file argument to the absolute path backups are kept under
+func backupTargetPath(file string) (string, error) {
+	if rest, ok := strings.CutPrefix(file, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		file = filepath.Join(home, rest)
+	}
+	return filepath.Abs(file)
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/egoavara/codex-market/internal/config"
 	"github.com/spf13/cobra"
@@ -33,12 +34,16 @@ Available keys:
                            Values: auto, en-US, ko-KR, etc.
   claude.registry.share  - How to share registry with Claude
                            Values: sync, merge, ignore
+  offline                - Skip network operations and install from cache
+                           Values: true, false
 
 Example:
   codex-market config set locale ko-KR
-  codex-market config set claude.registry.share sync`,
-	Args: cobra.ExactArgs(2),
-	RunE: runConfigSet,
+  codex-market config set claude.registry.share sync
+  codex-market config set offline true`,
+	Args:        cobra.ExactArgs(2),
+	Annotations: lockState,
+	RunE:        runConfigSet,
 }
 
 func init() {
@@ -53,6 +58,7 @@ func runConfigShow(cmd *cobra.Command, args []string) error {
 	fmt.Println("----------------------------------------")
 	fmt.Printf("  locale: %s\n", cfg.Locale)
 	fmt.Printf("  claude.registry.share: %s\n", cfg.Claude.Registry.Share)
+	fmt.Printf("  offline: %t\n", cfg.Offline)
 	fmt.Println()
 	fmt.Printf("  Marketplaces: %d registered\n", len(cfg.Marketplaces))
 
@@ -101,6 +107,14 @@ func runConfigSet(cmd *cobra.Command, args []string) error {
 		default:
 			return fmt.Errorf("invalid value '%s' for %s. Valid values: sync, merge, ignore", value, key)
 		}
+	case "offline":
+		offline, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid value '%s' for %s. Valid values: true, false", value, key)
+		}
+		cfg := config.Get()
+		cfg.Offline = offline
+		return config.Save(cfg)
 	default:
 		return fmt.Errorf("unknown config key: %s", key)
 	}
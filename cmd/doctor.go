@@ -0,0 +1,354 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/egoavara/codex-market/internal/config"
+	"github.com/egoavara/codex-market/internal/i18n"
+	"github.com/egoavara/codex-market/internal/mcp"
+	"github.com/egoavara/codex-market/internal/plugin"
+	"github.com/spf13/cobra"
+)
+
+var doctorFix bool
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check installed plugins against the filesystem and config.toml",
+	Long: `Cross-check installed.json against what is actually on disk:
+
+  - project-scope installs whose project directory no longer exists
+  - skills, commands and agents whose files are missing
+  - MCP servers that are recorded but missing from ~/.codex/config.toml
+  - codex-market marker blocks in config.toml that no installed plugin owns
+  - plugin cache versions no install refers to, and missing caches
+
+With --fix, installed.json is updated to match the filesystem, orphaned
+marker blocks and unused cache versions are removed, and plugins whose
+cache is missing are reinstalled. The repairs are made in one transaction:
+if any of them fails, nothing is changed.
+
+Example:
+  codex-market doctor
+  codex-market doctor --fix`,
+	Args:        cobra.NoArgs,
+	Annotations: lockState,
+	RunE:        runDoctor,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "Repair the problems found")
+}
+
+// doctorIssue is a problem found by doctor
+type doctorIssue struct {
+	kind    string
+	message string
+	// fix repairs the problem outside installed.json, journaling its changes
+	// in tx; nil if updating installed.json is enough. repaired is
+	// installed.json as it will be saved.
+	fix func(tx *plugin.Transaction, repaired *plugin.InstalledPlugins) error
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	installed, err := plugin.GetInstalled().Load()
+	if err != nil {
+		return err
+	}
+	blocks, err := mcp.MarkedBlocks(config.CodexConfigPath())
+	if err != nil {
+		return err
+	}
+
+	// repaired is installed.json as it should be; later checks run against it,
+	// so e.g. the MCP block of a removed dead-project install shows up as orphaned
+	repaired := &plugin.InstalledPlugins{Version: installed.Version, Plugins: make(map[string][]plugin.InstalledPluginEntry)}
+	var issues []doctorIssue
+
+	pluginIDs := make([]string, 0, len(installed.Plugins))
+	for id := range installed.Plugins {
+		pluginIDs = append(pluginIDs, id)
+	}
+	sort.Strings(pluginIDs)
+
+	for _, pluginID := range pluginIDs {
+		for _, entry := range installed.Plugins[pluginID] {
+			fixed, entryIssues, keep := checkInstalledEntry(pluginID, entry, blocks)
+			issues = append(issues, entryIssues...)
+			if keep {
+				repaired.Plugins[pluginID] = append(repaired.Plugins[pluginID], fixed)
+			}
+		}
+	}
+	issues = append(issues, checkMarkerBlocks(blocks, repaired)...)
+	cacheIssues, err := checkPluginCache(repaired)
+	if err != nil {
+		return err
+	}
+	issues = append(issues, cacheIssues...)
+
+	if len(issues) == 0 {
+		fmt.Println(i18n.T("DoctorHealthy", nil))
+		return nil
+	}
+
+	for _, issue := range issues {
+		fmt.Printf("  [%s] %s\n", issue.kind, issue.message)
+	}
+	fmt.Println()
+
+	if !doctorFix {
+		return fmt.Errorf("%s", i18n.T("DoctorProblemsFound", map[string]any{"Count": len(issues)}))
+	}
+
+	tx := plugin.BeginTransaction()
+	defer rollbackTransaction(tx)
+	failed := 0
+	for _, issue := range issues {
+		if issue.fix == nil {
+			continue
+		}
+		if err := issue.fix(tx, repaired); err != nil {
+			failed++
+			fmt.Printf("  Warning: failed to fix [%s] %s: %v\n", issue.kind, issue.message, err)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%s", i18n.T("DoctorFixFailed", map[string]any{"Count": failed}))
+	}
+
+	if err := tx.Modified(config.InstalledPath()); err != nil {
+		return err
+	}
+	if err := plugin.GetInstalled().Save(repaired); err != nil {
+		return err
+	}
+	tx.Commit()
+
+	fmt.Println(i18n.T("DoctorFixed", map[string]any{"Count": len(issues)}))
+	return plugin.PruneOriginals(repaired)
+}
+
+// checkInstalledEntry checks one install against the filesystem and the
+// marker blocks. It returns the entry without the missing parts, and keep is
+// false if the entry should be dropped entirely.
+func checkInstalledEntry(pluginID string, entry plugin.InstalledPluginEntry, blocks []mcp.MarkedBlock) (fixed plugin.InstalledPluginEntry, issues []doctorIssue, keep bool) {
+	label := pluginID
+	if entry.Scope == "project" {
+		label = fmt.Sprintf("%s (project %s)", pluginID, entry.ProjectPath)
+	}
+
+	if entry.Scope == "project" && entry.ProjectPath != "" && !pathExists(entry.ProjectPath) {
+		issues = append(issues, doctorIssue{
+			kind:    "dead-project",
+			message: fmt.Sprintf("%s: project directory no longer exists", label),
+		})
+		return entry, issues, false
+	}
+
+	fixed = entry
+	var missingPaths []string
+	missing := func(what, name, path string) {
+		missingPaths = append(missingPaths, path)
+		issues = append(issues, doctorIssue{
+			kind:    "missing-file",
+			message: fmt.Sprintf("%s: %s %s is missing (%s)", label, what, name, path),
+		})
+	}
+
+	fixed.Skills = nil
+	for _, s := range entry.Skills {
+		if !pathExists(s.Path) {
+			missing("skill", s.Name, s.Path)
+			continue
+		}
+		fixed.Skills = append(fixed.Skills, s)
+	}
+	fixed.Commands = nil
+	for _, c := range entry.Commands {
+		if !pathExists(c.Path) {
+			missing("command", c.Name, c.Path)
+			continue
+		}
+		fixed.Commands = append(fixed.Commands, c)
+	}
+	fixed.Agents = nil
+	for _, a := range entry.Agents {
+		if !pathExists(a.Path) {
+			missing("agent", a.Name, a.Path)
+			continue
+		}
+		fixed.Agents = append(fixed.Agents, a)
+	}
+
+	// Single files deleted from skill folders that are still there
+	fixed.Files = nil
+	for _, f := range entry.Files {
+		if pathExists(f.Path) {
+			fixed.Files = append(fixed.Files, f)
+			continue
+		}
+		reported := false
+		for _, p := range missingPaths {
+			if f.Path == p || strings.HasPrefix(f.Path, p+string(filepath.Separator)) {
+				reported = true
+				break
+			}
+		}
+		if !reported {
+			issues = append(issues, doctorIssue{
+				kind:    "missing-file",
+				message: fmt.Sprintf("%s: installed file %s is missing", label, f.Path),
+			})
+		}
+	}
+
+	// MCP servers are installed in a marker block named after the plugin
+	servers := make(map[string]bool)
+	pluginName, marketplaceName, _ := parsePluginID(pluginID)
+	for _, b := range blocks {
+		if b.Plugin == pluginName && b.Marketplace == marketplaceName {
+			for _, s := range b.Servers {
+				servers[s] = true
+			}
+		}
+	}
+	fixed.MCPServers = nil
+	for _, s := range entry.MCPServers {
+		if !servers[s.Name] {
+			issues = append(issues, doctorIssue{
+				kind:    "missing-mcp",
+				message: fmt.Sprintf("%s: MCP server %s is not in %s", label, s.Name, config.CodexConfigPath()),
+			})
+			continue
+		}
+		fixed.MCPServers = append(fixed.MCPServers, s)
+	}
+
+	if entry.Source.CachePath != "" && !pathExists(entry.Source.CachePath) {
+		issues = append(issues, doctorIssue{
+			kind:    "missing-cache",
+			message: fmt.Sprintf("%s: plugin cache is missing (%s)", label, entry.Source.CachePath),
+			fix: func(tx *plugin.Transaction, repaired *plugin.InstalledPlugins) error {
+				changes, err := decideLocalChanges(pluginID, fixed, true)
+				if err != nil {
+					return err
+				}
+				if err := reinstallPluginTx(tx, pluginID, fixed); err != nil {
+					return err
+				}
+				// The reinstall recorded a new entry, which replaces the repaired one
+				reinstalled, err := plugin.GetInstalled().GetByScope(pluginID, fixed.Scope, fixed.ProjectPath)
+				if err != nil {
+					return err
+				}
+				var entries []plugin.InstalledPluginEntry
+				for _, e := range repaired.Plugins[pluginID] {
+					if e.Scope != fixed.Scope || e.ProjectPath != fixed.ProjectPath {
+						entries = append(entries, e)
+					}
+				}
+				repaired.Plugins[pluginID] = append(entries, reinstalled...)
+				tx.OnCommit(func() { applyLocalChanges(changes) })
+				return nil
+			},
+		})
+	}
+
+	return fixed, issues, true
+}
+
+// checkMarkerBlocks finds marker blocks in config.toml that no install owns
+func checkMarkerBlocks(blocks []mcp.MarkedBlock, installed *plugin.InstalledPlugins) []doctorIssue {
+	// Blocks are removed by plugin name, so also track names in use
+	owned := make(map[string]bool)
+	namesInUse := make(map[string]bool)
+	for pluginID, entries := range installed.Plugins {
+		for _, e := range entries {
+			if len(e.MCPServers) > 0 {
+				owned[pluginID] = true
+				name, _, _ := parsePluginID(pluginID)
+				namesInUse[name] = true
+			}
+		}
+	}
+
+	var issues []doctorIssue
+	configPath := config.CodexConfigPath()
+	for _, b := range blocks {
+		pluginID := b.Plugin + "@" + b.Marketplace
+		if owned[pluginID] {
+			continue
+		}
+		issues = append(issues, doctorIssue{
+			kind:    "orphaned-marker",
+			message: fmt.Sprintf("%s has MCP servers for %s (%s), but it is not installed", configPath, pluginID, strings.Join(b.Servers, ", ")),
+			fix: func(tx *plugin.Transaction, _ *plugin.InstalledPlugins) error {
+				if namesInUse[b.Plugin] {
+					return fmt.Errorf("another installed plugin named %s shares this marker; remove the block manually", b.Plugin)
+				}
+				if err := tx.Modified(configPath); err != nil {
+					return err
+				}
+				return mcp.RemoveMCPServers(configPath, b.Plugin)
+			},
+		})
+	}
+	return issues
+}
+
+// checkPluginCache finds plugin cache versions no install refers to
+func checkPluginCache(installed *plugin.InstalledPlugins) ([]doctorIssue, error) {
+	tracked := make(map[string]bool)
+	for _, entries := range installed.Plugins {
+		for _, e := range entries {
+			if e.Source.CachePath != "" {
+				tracked[filepath.Clean(e.Source.CachePath)] = true
+			}
+		}
+	}
+
+	// Layout: <cache>/<marketplace>/<plugin>/<version>
+	versions, err := filepath.Glob(filepath.Join(config.PluginCacheDir(), "*", "*", "*"))
+	if err != nil {
+		return nil, err
+	}
+	var issues []doctorIssue
+	for _, dir := range versions {
+		if tracked[filepath.Clean(dir)] {
+			continue
+		}
+		issues = append(issues, doctorIssue{
+			kind:    "untracked-cache",
+			message: fmt.Sprintf("plugin cache %s is not used by any install", dir),
+			fix: func(tx *plugin.Transaction, _ *plugin.InstalledPlugins) error {
+				// Offline installs fall back to the cache, so keep it
+				if config.IsOffline() {
+					return config.ErrOffline
+				}
+				if err := tx.Remove(dir); err != nil {
+					return err
+				}
+				// Drop plugin and marketplace directories left empty
+				tx.OnCommit(func() {
+					pluginDir := filepath.Dir(dir)
+					if os.Remove(pluginDir) == nil {
+						os.Remove(filepath.Dir(pluginDir))
+					}
+				})
+				return nil
+			},
+		})
+	}
+	return issues, nil
+}
+
+func pathExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
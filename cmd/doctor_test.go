@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// orphanedMarker is an MCP marker block of a plugin that isn't installed
+const orphanedMarker = "\n# [codex-market:start] plugin=ghost marketplace=test-market\n[mcp_servers.ghost-server]\ncommand = \"echo\"\n# [codex-market:end] plugin=ghost\n"
+
+func TestDoctor(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, home string)
+		kind  string // kind of the issue doctor must report
+	}{
+		{
+			name: "dead project",
+			setup: func(t *testing.T, home string) {
+				project := filepath.Join(t.TempDir(), "project")
+				if err := os.MkdirAll(project, 0755); err != nil {
+					t.Fatal(err)
+				}
+				if out, err := runCLIIn(t, home, project, nil, "install", "--scope", "project", "demo@test-market"); err != nil {
+					t.Fatalf("install: %v\n%s", err, out)
+				}
+				if err := os.RemoveAll(project); err != nil {
+					t.Fatal(err)
+				}
+			},
+			kind: "dead-project",
+		},
+		{
+			name: "missing skill",
+			setup: func(t *testing.T, home string) {
+				if err := os.RemoveAll(filepath.Join(home, ".codex", "skills", "demo-skill")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			kind: "missing-file",
+		},
+		{
+			name: "missing cache",
+			setup: func(t *testing.T, home string) {
+				if err := os.RemoveAll(filepath.Join(home, ".config", "codex-market", "cache", "test-market", "demo")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			kind: "missing-cache",
+		},
+		{
+			name: "orphaned marker block",
+			setup: func(t *testing.T, home string) {
+				f, err := os.OpenFile(filepath.Join(home, ".codex", "config.toml"), os.O_APPEND|os.O_WRONLY, 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer f.Close()
+				if _, err := f.WriteString(orphanedMarker); err != nil {
+					t.Fatal(err)
+				}
+			},
+			kind: "orphaned-marker",
+		},
+		{
+			name: "untracked cache",
+			setup: func(t *testing.T, home string) {
+				writeFiles(t, home, map[string]string{".config/codex-market/cache/test-market/ghost/1.0.0/README.md": "ghost"})
+			},
+			kind: "untracked-cache",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			newTestMarketplace(t, home, "demo")
+			if out, err := runCLI(t, home, nil, "install", "demo@test-market"); err != nil {
+				t.Fatalf("install: %v\n%s", err, out)
+			}
+			if out, err := runCLI(t, home, nil, "doctor"); err != nil {
+				t.Fatalf("doctor before setup: %v\n%s", err, out)
+			}
+			tt.setup(t, home)
+
+			out, err := runCLI(t, home, nil, "doctor")
+			if err == nil || !strings.Contains(out, "["+tt.kind+"]") {
+				t.Fatalf("doctor did not report %s: %v\n%s", tt.kind, err, out)
+			}
+			if out, err := runCLI(t, home, nil, "doctor", "--fix"); err != nil {
+				t.Fatalf("doctor --fix: %v\n%s", err, out)
+			}
+			if out, err := runCLI(t, home, nil, "doctor"); err != nil {
+				t.Errorf("problems left after doctor --fix: %v\n%s", err, out)
+			}
+		})
+	}
+}
+
+func TestDoctorFixFailureChangesNothing(t *testing.T) {
+	home := t.TempDir()
+	newTestMarketplace(t, home, "demo")
+	if out, err := runCLI(t, home, nil, "install", "demo@test-market"); err != nil {
+		t.Fatalf("install: %v\n%s", err, out)
+	}
+	if err := os.RemoveAll(filepath.Join(home, ".codex", "skills", "demo-skill")); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, home, map[string]string{".config/codex-market/cache/test-market/ghost/1.0.0/README.md": "ghost"})
+	before := snapshotHome(t, home)
+
+	// Untracked caches are kept offline, so that fix fails
+	out, err := runCLI(t, home, nil, "--offline", "doctor", "--fix")
+	if err == nil {
+		t.Fatalf("doctor --fix succeeded offline:\n%s", out)
+	}
+	if diffs := diffSnapshots(before, snapshotHome(t, home)); len(diffs) > 0 {
+		t.Errorf("failed fix was not rolled back:\n%s\n%s", strings.Join(diffs, "\n"), out)
+	}
+}
@@ -49,8 +49,15 @@ func runList(cmd *cobra.Command, args []string) error {
 	} else {
 		for name, mp := range marketplaces {
 			fmt.Printf("  %s\n", name)
-			fmt.Printf("    URL: %s\n", mp.Source.URL)
-			fmt.Printf("    Path: %s\n", mp.InstallLocation)
+			if mp.Source.Source == marketplace.SourceDirectory {
+				fmt.Printf("    Directory: %s\n", mp.Source.Path)
+			} else {
+				fmt.Printf("    URL: %s\n", mp.Source.URL)
+				if mp.ActiveURL != "" && mp.ActiveURL != mp.Source.URL {
+					fmt.Printf("    Mirror: %s\n", mp.ActiveURL)
+				}
+				fmt.Printf("    Path: %s\n", mp.InstallLocation)
+			}
 			fmt.Printf("    Updated: %s\n", mp.LastUpdated)
 
 			// Show available plugins if --all flag
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/egoavara/codex-market/internal/i18n"
+)
+
+// TestMain runs the test binary as the codex-market CLI when asked to. Paths
+// are derived from $HOME once at startup, so each CLI run is a subprocess
+// with its own HOME.
+func TestMain(m *testing.M) {
+	if os.Getenv("CODEX_MARKET_TEST_CLI") != "1" {
+		os.Exit(m.Run())
+	}
+
+	if step := os.Getenv("CODEX_MARKET_TEST_FAIL_AFTER"); step != "" {
+		failAfter = func(s string) error {
+			if s == step {
+				return fmt.Errorf("injected failure after %s", s)
+			}
+			return nil
+		}
+	}
+	i18n.Init(embed.FS{}, "en")
+	RegisterPluginAliases()
+	Execute()
+	os.Exit(0)
+}
+
+// runCLI runs codex-market with the given HOME and extra environment
+func runCLI(t *testing.T, home string, env []string, args ...string) (string, error) {
+	t.Helper()
+	return runCLIIn(t, home, home, env, args...)
+}
+
+// runCLIIn is runCLI with dir as the working directory, e.g. for project scope
+func runCLIIn(t *testing.T, home, dir string, env []string, args ...string) (string, error) {
+	t.Helper()
+	c := exec.Command(os.Args[0], args...)
+	c.Dir = dir
+	c.Env = append(os.Environ(), "HOME="+home, "USERPROFILE="+home, "CODEX_MARKET_TEST_CLI=1")
+	c.Env = append(c.Env, env...)
+	out, err := c.CombinedOutput()
+	return string(out), err
+}
+
+// writeFiles creates files under root from a map of slash-separated paths
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// newTestMarketplace creates a directory marketplace whose plugins each have
+// a skill, a command, an agent and an MCP server, registers it in home and
+// returns its directory
+func newTestMarketplace(t *testing.T, home string, plugins ...string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "market")
+
+	var entries []string
+	files := make(map[string]string)
+	for _, name := range plugins {
+		entries = append(entries, fmt.Sprintf(`{"name":%q,"source":"./plugins/%s","version":"1.0.0"}`, name, name))
+		root := "plugins/" + name + "/"
+		files[root+".claude-plugin/plugin.json"] = fmt.Sprintf(`{"name":%q,"version":"1.0.0"}`, name)
+		files[root+"skills/"+name+"-skill/SKILL.md"] = "---\nname: " + name + "-skill\ndescription: A test skill\n---\n\nDo the thing.\n"
+		files[root+"commands/"+name+"-cmd.md"] = "---\ndescription: A test command\n---\n\nRun $ARGUMENTS.\n"
+		files[root+"agents/"+name+"-agent.md"] = "---\nname: " + name + "-agent\ndescription: A test agent\n---\n\nReview the code.\n"
+		files[root+".mcp.json"] = fmt.Sprintf(`{"mcpServers":{"%s-server":{"command":"echo","args":["${CLAUDE_PLUGIN_ROOT}"]}}}`, name)
+	}
+	files[".claude-plugin/marketplace.json"] = fmt.Sprintf(`{"name":"test-market","owner":{"name":"test"},"plugins":[%s]}`, strings.Join(entries, ","))
+	writeFiles(t, dir, files)
+
+	if out, err := runCLI(t, home, nil, "marketplace", "add", dir); err != nil {
+		t.Fatalf("marketplace add: %v\n%s", err, out)
+	}
+	return dir
+}
+
+// snapshotHome returns the contents of every file and directory under home,
+// leaving out state that is kept on purpose even when an operation fails
+func snapshotHome(t *testing.T, home string) map[string]string {
+	t.Helper()
+	marketDir := filepath.Join(home, ".config", "codex-market")
+	skip := map[string]bool{
+		filepath.Join(marketDir, "backups"): true,
+		filepath.Join(marketDir, "lock"):    true,
+	}
+
+	snapshot := make(map[string]string)
+	err := filepath.WalkDir(home, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if skip[path] {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, _ := filepath.Rel(home, path)
+		if d.IsDir() {
+			snapshot[rel] = "dir"
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		snapshot[rel] = hex.EncodeToString(sum[:])
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return snapshot
+}
+
+// diffSnapshots describes how two snapshots differ
+func diffSnapshots(before, after map[string]string) []string {
+	var diffs []string
+	for path, v := range before {
+		if w, ok := after[path]; !ok {
+			diffs = append(diffs, "removed "+path)
+		} else if v != w {
+			diffs = append(diffs, "changed "+path)
+		}
+	}
+	for path := range after {
+		if _, ok := before[path]; !ok {
+			diffs = append(diffs, "added "+path)
+		}
+	}
+	return diffs
+}
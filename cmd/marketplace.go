@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 
@@ -20,22 +22,52 @@ var marketplaceCmd = &cobra.Command{
 	Long: `Manage plugin marketplaces (similar to 'brew tap').
 
 Commands:
-  add     Add a new marketplace from git URL
-  del     Remove a registered marketplace
-  list    List all registered marketplaces
-  update  Update marketplace(s)`,
+  add       Add a new marketplace from git URL or local directory
+  del       Remove a registered marketplace
+  list      List all registered marketplaces
+  update    Update marketplace(s)
+  validate  Check a marketplace directory for problems
+  init      Create a new marketplace
+  pin       Pin a marketplace to a branch, tag or commit
+  unpin     Follow the default branch again
+  auth      Configure credentials for a private marketplace
+  mirror    Configure mirror URLs and host rewrites`,
 }
 
 var marketplaceAddCmd = &cobra.Command{
-	Use:   "add <git-url>",
+	Use:   "add <git-url | path>",
 	Short: "Add a plugin marketplace repository",
-	Long: `Add a plugin marketplace repository from a git URL.
+	Long: `Add a plugin marketplace repository from a git URL or a local directory.
+
+Local directories are registered in place without cloning, so changes to the
+marketplace are picked up immediately. Paths starting with ./, ../, / or ~
+are treated as directories; use --dir to force it.
+
+Git marketplaces can be pinned to a branch, tag or commit with url#ref,
+--ref or --branch. Updates then stay on the pinned ref.
+
+Private repositories can be accessed with --token-env, --credential-helper or
+--ssh-key instead of the global git configuration (see 'marketplace auth').
+
+--mirror and --rewrite add fallback URLs that are tried in order when the
+repository can't be reached (see 'marketplace mirror').
+
+HTTP(S) URLs ending in .json are fetched as a published marketplace.json
+without git. Plugins in such marketplaces must use url or github sources.
 
 Example:
   codex-market marketplace add https://github.com/org/my-plugins
-  codex-market mp add git@github.com:org/my-plugins.git`,
-	Args: cobra.ExactArgs(1),
-	RunE: runMarketplaceAdd,
+  codex-market mp add git@github.com:org/my-plugins.git
+  codex-market mp add https://github.com/org/my-plugins#v1.4.0
+  codex-market mp add https://github.com/org/my-plugins --branch stable
+  codex-market mp add https://git.example.com/team/plugins --token-env PLUGINS_TOKEN
+  codex-market mp add https://github.com/org/my-plugins --rewrite github.com=git.corp.example
+  codex-market mp add ./my-plugins
+  codex-market mp add --dir my-plugins
+  codex-market mp add https://example.com/plugins/marketplace.json`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: lockState,
+	RunE:        runMarketplaceAdd,
 }
 
 var marketplaceDelCmd = &cobra.Command{
@@ -46,8 +78,9 @@ var marketplaceDelCmd = &cobra.Command{
 
 Example:
   codex-market marketplace del my-marketplace`,
-	Args: cobra.ExactArgs(1),
-	RunE: runMarketplaceDel,
+	Args:        cobra.ExactArgs(1),
+	Annotations: lockState,
+	RunE:        runMarketplaceDel,
 }
 
 var marketplaceListCmd = &cobra.Command{
@@ -69,30 +102,254 @@ var marketplaceUpdateCmd = &cobra.Command{
 Example:
   codex-market marketplace update              # Update all
   codex-market marketplace update my-marketplace  # Update specific`,
-	RunE: runMarketplaceUpdate,
+	Annotations: lockState,
+	RunE:        runMarketplaceUpdate,
+}
+
+var marketplaceValidateCmd = &cobra.Command{
+	Use:   "validate [dir]",
+	Short: "Check a marketplace directory for problems",
+	Long: `Lint a marketplace directory before publishing it.
+
+Checks marketplace.json against the marketplace schema, that every local
+plugin source exists, that each plugin.json parses and its component paths
+exist, that SKILL.md files have valid frontmatter, and that MCP configs parse.
+All problems are reported with their file and field; the command exits with
+a non-zero status if any error is found.
+
+Example:
+  codex-market marketplace validate            # Current directory
+  codex-market marketplace validate ./my-marketplace
+  codex-market marketplace validate --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runMarketplaceValidate,
+}
+
+var marketplaceInitCmd = &cobra.Command{
+	Use:   "init [dir]",
+	Short: "Create a new marketplace",
+	Long: `Create .claude-plugin/marketplace.json in a directory, optionally
+together with a first plugin.
+
+Example:
+  codex-market marketplace init                       # Current directory
+  codex-market marketplace init ./my-marketplace --owner "My Team"
+  codex-market marketplace init ./my-marketplace --plugin my-plugin --mcp`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runMarketplaceInit,
+}
+
+var marketplacePinCmd = &cobra.Command{
+	Use:   "pin <name> <ref>",
+	Short: "Pin a marketplace to a branch, tag or commit",
+	Long: `Pin a git marketplace to a branch, tag or commit and check it out.
+
+Branch pins follow their branch on update; tag and commit pins never move,
+but update checks report newer tags for tag pins.
+
+Example:
+  codex-market marketplace pin my-marketplace v1.4.0
+  codex-market marketplace pin my-marketplace stable
+  codex-market marketplace pin my-marketplace 1a2b3c4d`,
+	Args:        cobra.ExactArgs(2),
+	Annotations: lockState,
+	RunE:        runMarketplacePin,
+}
+
+var marketplaceUnpinCmd = &cobra.Command{
+	Use:   "unpin <name>",
+	Short: "Remove a marketplace pin and follow the default branch",
+	Long: `Remove a marketplace pin and check out the remote's default branch.
+
+Example:
+  codex-market marketplace unpin my-marketplace`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: lockState,
+	RunE:        runMarketplaceUnpin,
+}
+
+var marketplaceAuthCmd = &cobra.Command{
+	Use:   "auth <name>",
+	Short: "Configure credentials for a private marketplace",
+	Long: `Configure the credentials used to clone, fetch and pull a git marketplace.
+They are also used for url/github plugin sources hosted on the same host.
+
+Credentials are passed to git through its environment; tokens are read from
+the named environment variable each time and never stored. Without flags the
+current settings are shown.
+
+Example:
+  codex-market marketplace auth my-marketplace --token-env PLUGINS_TOKEN
+  codex-market marketplace auth my-marketplace --credential-helper "!gh auth git-credential"
+  codex-market marketplace auth my-marketplace --ssh-key ~/.ssh/plugins_ed25519
+  codex-market marketplace auth my-marketplace --clear`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: lockState,
+	RunE:        runMarketplaceAuth,
+}
+
+var marketplaceMirrorCmd = &cobra.Command{
+	Use:   "mirror <name>",
+	Short: "Configure mirror URLs and host rewrites for a marketplace",
+	Long: `Configure fallback URLs for a git marketplace. When the source URL can't
+be cloned or fetched, the mirrors are tried in order, followed by the source
+URL with each host rewrite applied. The URL that worked is remembered and
+tried first next time.
+
+Host rewrites also apply to url/github plugin sources, so plugins hosted on a
+blocked host are fetched from the mirror as well.
+
+The given lists replace the current ones; without flags the current settings
+are shown.
+
+Example:
+  codex-market marketplace mirror my-plugins --rewrite github.com=git.corp.example
+  codex-market marketplace mirror my-plugins --mirror https://git.corp.example/org/my-plugins.git
+  codex-market marketplace mirror my-plugins --clear`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: lockState,
+	RunE:        runMarketplaceMirror,
 }
 
 var (
-	marketplaceListAll bool
+	marketplaceListAll      bool
+	marketplaceAddDir       bool
+	marketplaceAddRef       string
+	marketplaceAddBranch    string
+	marketplaceValidateJSON bool
+
+	marketplaceInitName        string
+	marketplaceInitOwner       string
+	marketplaceInitDescription string
+	marketplaceInitPlugin      string
+
+	// Credential flags shared by add and auth
+	marketplaceAuthTokenEnv string
+	marketplaceAuthUsername string
+	marketplaceAuthHelper   string
+	marketplaceAuthSSHKey   string
+	marketplaceAuthClear    bool
+
+	// Mirror flags shared by add and mirror
+	marketplaceMirrors     []string
+	marketplaceRewrites    []string
+	marketplaceMirrorClear bool
 )
 
 func init() {
 	marketplaceListCmd.Flags().BoolVarP(&marketplaceListAll, "all", "a", false, "show available plugins from marketplaces")
+	marketplaceAddCmd.Flags().BoolVar(&marketplaceAddDir, "dir", false, "register a local directory in place instead of cloning")
+	marketplaceAddCmd.Flags().StringVar(&marketplaceAddRef, "ref", "", "pin to a branch, tag or commit")
+	marketplaceAddCmd.Flags().StringVar(&marketplaceAddBranch, "branch", "", "pin to a branch")
+	addAuthFlags(marketplaceAddCmd)
+	addAuthFlags(marketplaceAuthCmd)
+	marketplaceAuthCmd.Flags().BoolVar(&marketplaceAuthClear, "clear", false, "remove the configured credentials")
+	addMirrorFlags(marketplaceAddCmd)
+	addMirrorFlags(marketplaceMirrorCmd)
+	marketplaceMirrorCmd.Flags().BoolVar(&marketplaceMirrorClear, "clear", false, "remove all mirrors and rewrites")
+	marketplaceValidateCmd.Flags().BoolVar(&marketplaceValidateJSON, "json", false, "output the report as JSON")
+	marketplaceInitCmd.Flags().StringVar(&marketplaceInitName, "name", "", "marketplace name (default: directory name)")
+	marketplaceInitCmd.Flags().StringVar(&marketplaceInitOwner, "owner", "", "owner name (default: git user.name)")
+	marketplaceInitCmd.Flags().StringVar(&marketplaceInitDescription, "description", "", "marketplace description")
+	marketplaceInitCmd.Flags().StringVar(&marketplaceInitPlugin, "plugin", "", "also create a first plugin with this name")
+	marketplaceInitCmd.Flags().BoolVar(&pluginNewMCP, "mcp", false, "include an example .mcp.json in the first plugin")
 
 	marketplaceCmd.AddCommand(marketplaceAddCmd)
 	marketplaceCmd.AddCommand(marketplaceDelCmd)
 	marketplaceCmd.AddCommand(marketplaceListCmd)
 	marketplaceCmd.AddCommand(marketplaceUpdateCmd)
+	marketplaceCmd.AddCommand(marketplaceValidateCmd)
+	marketplaceCmd.AddCommand(marketplaceInitCmd)
+	marketplaceCmd.AddCommand(marketplacePinCmd)
+	marketplaceCmd.AddCommand(marketplaceUnpinCmd)
+	marketplaceCmd.AddCommand(marketplaceAuthCmd)
+	marketplaceCmd.AddCommand(marketplaceMirrorCmd)
+}
+
+// addAuthFlags registers the credential flags on a command
+func addAuthFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&marketplaceAuthTokenEnv, "token-env", "", "environment variable holding an HTTPS access token")
+	cmd.Flags().StringVar(&marketplaceAuthUsername, "username", "", "user name sent with the token (default: x-access-token)")
+	cmd.Flags().StringVar(&marketplaceAuthHelper, "credential-helper", "", "git credential helper to use")
+	cmd.Flags().StringVar(&marketplaceAuthSSHKey, "ssh-key", "", "private key file for SSH remotes")
+}
+
+// addMirrorFlags registers the mirror flags on a command
+func addMirrorFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&marketplaceMirrors, "mirror", nil, "fallback URL to try when the source fails (repeatable)")
+	cmd.Flags().StringArrayVar(&marketplaceRewrites, "rewrite", nil, "host rewrite rule FROM=TO, e.g. github.com=git.corp.example (repeatable)")
+}
+
+// rewritesFromFlags parses the --rewrite rules ("from=to" or "from -> to")
+func rewritesFromFlags() ([]config.HostRewrite, error) {
+	var rules []config.HostRewrite
+	for _, rule := range marketplaceRewrites {
+		from, to, ok := strings.Cut(rule, "->")
+		if !ok {
+			from, to, ok = strings.Cut(rule, "=")
+		}
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("invalid rewrite rule %q: expected FROM=TO", rule)
+		}
+		rules = append(rules, config.HostRewrite{From: from, To: to})
+	}
+	return rules, nil
+}
+
+// authFromFlags returns the credentials given on the command line, or nil if none
+func authFromFlags() *config.MarketplaceAuth {
+	auth := &config.MarketplaceAuth{
+		TokenEnv:         marketplaceAuthTokenEnv,
+		Username:         marketplaceAuthUsername,
+		CredentialHelper: marketplaceAuthHelper,
+		SSHKey:           marketplaceAuthSSHKey,
+	}
+	if *auth == (config.MarketplaceAuth{}) {
+		return nil
+	}
+	return auth
 }
 
 func runMarketplaceAdd(cmd *cobra.Command, args []string) error {
 	url := args[0]
+	auth := authFromFlags()
+	rewrites, err := rewritesFromFlags()
+	if err != nil {
+		return err
+	}
+
+	if marketplaceAddDir || isLocalPath(url) || marketplace.IsManifestURL(url) {
+		if auth != nil {
+			return fmt.Errorf("credentials can only be configured for git marketplaces")
+		}
+		if len(marketplaceMirrors) > 0 || len(rewrites) > 0 {
+			return fmt.Errorf("mirrors can only be configured for git marketplaces")
+		}
+		if marketplace.IsManifestURL(url) {
+			return addURLMarketplace(url)
+		}
+		return addDirectoryMarketplace(url)
+	}
+
+	// Split an optional url#ref pin; --branch and --ref take precedence
+	url, ref := git.SplitRef(url)
+	if marketplaceAddRef != "" {
+		ref = marketplaceAddRef
+	}
+	if marketplaceAddBranch != "" {
+		ref = marketplaceAddBranch
+	}
 
 	// Extract repository name from URL
 	repoName := extractRepoName(url)
 	if repoName == "" {
 		return fmt.Errorf("failed to extract repository name from URL: %s", url)
 	}
+	destPath, err := marketplaceInstallPath(repoName)
+	if err != nil {
+		return err
+	}
 
 	// Check if already exists
 	registry := marketplace.GetRegistry()
@@ -101,7 +358,7 @@ func runMarketplaceAdd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	if exists {
-		return fmt.Errorf(i18n.T("AlreadyExists", map[string]any{"Name": repoName}))
+		return fmt.Errorf("%s", i18n.T("AlreadyExists", map[string]any{"Name": repoName}))
 	}
 
 	// Ensure marketplaces directory exists
@@ -110,15 +367,32 @@ func runMarketplaceAdd(cmd *cobra.Command, args []string) error {
 	}
 
 	// Clone the repository
-	destPath := filepath.Join(config.MarketplacesDir(), repoName)
 	gitClient := git.NewClient()
+	gitClient.Auth = auth
+	gitClient.Mirrors = marketplaceMirrors
+	gitClient.Rewrites = rewrites
+
+	var refKind git.RefKind
+	if ref != "" {
+		refKind = git.RefBranch
+		if marketplaceAddBranch == "" {
+			if refKind, err = gitClient.ResolveRef(url, ref); err != nil {
+				return err
+			}
+		}
+	}
 
 	fmt.Printf("Cloning %s...\n", url)
-	if err := gitClient.Clone(url, destPath); err != nil {
+	if ref != "" {
+		err = gitClient.CloneRef(url, destPath, ref, refKind)
+	} else {
+		err = gitClient.Clone(url, destPath)
+	}
+	if err != nil {
 		if authErr, ok := err.(*git.AuthError); ok {
-			return fmt.Errorf(i18n.T("GitAuthFailed", map[string]any{"URL": authErr.URL}))
+			return fmt.Errorf("%s", i18n.T("GitAuthFailed", map[string]any{"URL": authErr.URL}))
 		}
-		return fmt.Errorf(i18n.T("GitCloneFailed", map[string]any{"Error": err.Error()}))
+		return fmt.Errorf("%s", i18n.T("GitCloneFailed", map[string]any{"Error": err.Error()}))
 	}
 
 	// Load and validate marketplace manifest
@@ -126,7 +400,7 @@ func runMarketplaceAdd(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		// Rollback: remove cloned directory
 		os.RemoveAll(destPath)
-		return fmt.Errorf(i18n.T("InvalidManifest", map[string]any{"Path": destPath}))
+		return fmt.Errorf("%s", i18n.T("InvalidManifest", map[string]any{"Path": destPath}))
 	}
 
 	// Use the name from manifest if available
@@ -134,12 +408,39 @@ func runMarketplaceAdd(cmd *cobra.Command, args []string) error {
 	if marketplaceName == "" {
 		marketplaceName = repoName
 	}
+	if err := marketplace.ValidateName(marketplaceName); err != nil {
+		os.RemoveAll(destPath)
+		return err
+	}
 
 	// Register the marketplace
-	if err := registry.Add(marketplaceName, url, destPath); err != nil {
+	source := marketplace.MarketplaceSource{Source: marketplace.SourceGit, URL: url}
+	if err := registry.Add(marketplaceName, source, destPath); err != nil {
 		os.RemoveAll(destPath)
 		return err
 	}
+	if ref != "" {
+		if err := registry.SetPin(marketplaceName, ref, string(refKind)); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if err := registry.SetAuth(marketplaceName, auth); err != nil {
+			return err
+		}
+	}
+	if len(marketplaceMirrors) > 0 || len(rewrites) > 0 {
+		if err := registry.SetMirrors(marketplaceName, marketplaceMirrors, rewrites); err != nil {
+			return err
+		}
+		// Remember which URL the clone came from
+		if active, err := gitClient.RemoteURL(destPath); err == nil && active != url {
+			fmt.Println(i18n.T("MirrorUsed", map[string]any{"URL": active}))
+			if err := config.RecordActiveURL(url, active); err != nil {
+				return err
+			}
+		}
+	}
 
 	// Success message
 	pluginCount := len(manifest.Plugins)
@@ -151,6 +452,134 @@ func runMarketplaceAdd(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// addDirectoryMarketplace registers a local marketplace directory in place
+func addDirectoryMarketplace(path string) error {
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		path = filepath.Join(home, path[2:])
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(absPath); err != nil || !info.IsDir() {
+		return fmt.Errorf("directory not found: %s", path)
+	}
+
+	manifest, err := marketplace.LoadManifest(absPath)
+	if err != nil {
+		return fmt.Errorf("%s", i18n.T("InvalidManifest", map[string]any{"Path": absPath}))
+	}
+
+	marketplaceName := manifest.Name
+	if marketplaceName == "" {
+		marketplaceName = filepath.Base(absPath)
+	}
+	if err := marketplace.ValidateName(marketplaceName); err != nil {
+		return err
+	}
+
+	registry := marketplace.GetRegistry()
+	exists, err := registry.Exists(marketplaceName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%s", i18n.T("AlreadyExists", map[string]any{"Name": marketplaceName}))
+	}
+
+	source := marketplace.MarketplaceSource{Source: marketplace.SourceDirectory, Path: absPath}
+	if err := registry.Add(marketplaceName, source, absPath); err != nil {
+		return err
+	}
+
+	pluginCount := len(manifest.Plugins)
+	fmt.Println(i18n.T("AddSuccess", map[string]any{
+		"Name":  marketplaceName,
+		"Count": pluginCount,
+	}, pluginCount))
+
+	return nil
+}
+
+// addURLMarketplace fetches a published marketplace.json and stores it under the marketplaces directory
+func addURLMarketplace(url string) error {
+	fmt.Printf("Fetching %s...\n", url)
+	fetcher := marketplace.NewFetcher()
+	data, info, err := fetcher.Fetch(url, marketplace.FetchInfo{})
+	if err != nil {
+		return err
+	}
+
+	var manifest marketplace.MarketplaceManifest
+	if err := json.Unmarshal(data, &manifest); err != nil || manifest.Name == "" {
+		return fmt.Errorf("%s", i18n.T("InvalidManifest", map[string]any{"Path": url}))
+	}
+	marketplaceName := manifest.Name
+	destPath, err := marketplaceInstallPath(marketplaceName)
+	if err != nil {
+		return err
+	}
+
+	registry := marketplace.GetRegistry()
+	exists, err := registry.Exists(marketplaceName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%s", i18n.T("AlreadyExists", map[string]any{"Name": marketplaceName}))
+	}
+
+	if err := marketplace.StoreManifest(destPath, data); err != nil {
+		return err
+	}
+
+	source := marketplace.MarketplaceSource{Source: marketplace.SourceURL, URL: url}
+	if err := registry.Add(marketplaceName, source, destPath); err != nil {
+		os.RemoveAll(destPath)
+		return err
+	}
+	if err := registry.SetFetchInfo(marketplaceName, info); err != nil {
+		return err
+	}
+
+	pluginCount := len(manifest.Plugins)
+	fmt.Println(i18n.T("AddSuccess", map[string]any{
+		"Name":  marketplaceName,
+		"Count": pluginCount,
+	}, pluginCount))
+
+	return nil
+}
+
+// marketplaceInstallPath returns the directory a fetched marketplace is stored in,
+// rejecting names that would place it outside the marketplaces directory
+func marketplaceInstallPath(name string) (string, error) {
+	if err := marketplace.ValidateName(name); err != nil {
+		return "", err
+	}
+	root := config.MarketplacesDir()
+	destPath := filepath.Clean(filepath.Join(root, name))
+	if rel, err := filepath.Rel(root, destPath); err != nil || rel != name {
+		return "", fmt.Errorf("invalid marketplace name %q: resolves outside %s", name, root)
+	}
+	return destPath, nil
+}
+
+// isLocalPath reports whether a marketplace argument looks like a filesystem path
+func isLocalPath(arg string) bool {
+	for _, prefix := range []string{"./", "../", "/", "~/"} {
+		if strings.HasPrefix(arg, prefix) {
+			return true
+		}
+	}
+	return arg == "." || arg == ".."
+}
+
 func runMarketplaceDel(cmd *cobra.Command, args []string) error {
 	name := args[0]
 
@@ -160,11 +589,11 @@ func runMarketplaceDel(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	if mp == nil {
-		return fmt.Errorf(i18n.T("MarketplaceNotFound", map[string]any{"Name": name}))
+		return fmt.Errorf("%s", i18n.T("MarketplaceNotFound", map[string]any{"Name": name}))
 	}
 
-	// Remove the directory
-	if mp.InstallLocation != "" {
+	// Remove the cloned directory (directory marketplaces are left in place)
+	if mp.InstallLocation != "" && mp.Source.Source != marketplace.SourceDirectory {
 		if err := os.RemoveAll(mp.InstallLocation); err != nil {
 			fmt.Printf("Warning: failed to remove directory %s: %v\n", mp.InstallLocation, err)
 		}
@@ -196,8 +625,18 @@ func runMarketplaceList(cmd *cobra.Command, args []string) error {
 
 	for name, mp := range marketplaces {
 		fmt.Printf("  %s\n", name)
-		fmt.Printf("    URL: %s\n", mp.Source.URL)
-		fmt.Printf("    Path: %s\n", mp.InstallLocation)
+		if mp.Source.Source == marketplace.SourceDirectory {
+			fmt.Printf("    Directory: %s\n", mp.Source.Path)
+		} else {
+			fmt.Printf("    URL: %s\n", mp.Source.URL)
+			if mp.ActiveURL != "" && mp.ActiveURL != mp.Source.URL {
+				fmt.Printf("    Mirror: %s\n", mp.ActiveURL)
+			}
+			fmt.Printf("    Path: %s\n", mp.InstallLocation)
+		}
+		if mp.Ref != "" {
+			fmt.Printf("    Pinned: %s (%s)\n", mp.Ref, mp.RefKind)
+		}
 		fmt.Printf("    Updated: %s\n", mp.LastUpdated)
 
 		// Show available plugins if --all flag
@@ -267,6 +706,27 @@ func updateAllMarketplaces(gitClient *git.DefaultClient, registry *marketplace.R
 
 	for name, mp := range marketplaces {
 		fmt.Printf("Updating %s...\n", name)
+		if mp.Source.Source == marketplace.SourceDirectory {
+			registry.UpdateTimestamp(name)
+			fmt.Printf("  Done (local directory)\n")
+			continue
+		}
+		if config.IsOffline() {
+			fmt.Println(i18n.T("OfflineSkipped", map[string]any{"Name": name}))
+			continue
+		}
+		if mp.Source.Source == marketplace.SourceURL {
+			if err := updateURLMarketplace(registry, name, mp); err != nil {
+				fmt.Printf("  Error: %s\n", err)
+				continue
+			}
+			fmt.Printf("  Done\n")
+			continue
+		}
+		if mp.IsPinnedToRevision() {
+			printPinnedStatus(gitClient, name, mp)
+			continue
+		}
 		if err := gitClient.Pull(mp.InstallLocation); err != nil {
 			if authErr, ok := err.(*git.AuthError); ok {
 				fmt.Printf("  Error: %s\n", i18n.T("GitAuthFailed", map[string]any{"URL": authErr.URL}))
@@ -289,18 +749,355 @@ func updateMarketplace(gitClient *git.DefaultClient, registry *marketplace.Regis
 		return err
 	}
 	if mp == nil {
-		return fmt.Errorf(i18n.T("MarketplaceNotFound", map[string]any{"Name": name}))
+		return fmt.Errorf("%s", i18n.T("MarketplaceNotFound", map[string]any{"Name": name}))
 	}
 
 	fmt.Printf("Updating %s...\n", name)
+	if mp.Source.Source == marketplace.SourceDirectory {
+		// Directory marketplaces are read live; just record the current manifest
+		registry.UpdateTimestamp(name)
+		fmt.Println(i18n.T("UpdateSuccess", map[string]any{"Target": name}))
+		return nil
+	}
+	if config.IsOffline() {
+		fmt.Println(i18n.T("OfflineSkipped", map[string]any{"Name": name}))
+		return nil
+	}
+	if mp.Source.Source == marketplace.SourceURL {
+		if err := updateURLMarketplace(registry, name, *mp); err != nil {
+			return err
+		}
+		fmt.Println(i18n.T("UpdateSuccess", map[string]any{"Target": name}))
+		return nil
+	}
+	if mp.IsPinnedToRevision() {
+		printPinnedStatus(gitClient, name, *mp)
+		return nil
+	}
 	if err := gitClient.Pull(mp.InstallLocation); err != nil {
 		if authErr, ok := err.(*git.AuthError); ok {
-			return fmt.Errorf(i18n.T("GitAuthFailed", map[string]any{"URL": authErr.URL}))
+			return fmt.Errorf("%s", i18n.T("GitAuthFailed", map[string]any{"URL": authErr.URL}))
 		}
-		return fmt.Errorf(i18n.T("GitPullFailed", map[string]any{"Error": err.Error()}))
+		return fmt.Errorf("%s", i18n.T("GitPullFailed", map[string]any{"Error": err.Error()}))
 	}
 
 	registry.UpdateTimestamp(name)
 	fmt.Println(i18n.T("UpdateSuccess", map[string]any{"Target": name}))
 	return nil
 }
+
+// printPinnedStatus explains why a tag or commit pinned marketplace was not
+// updated, listing newer tags for tag pins
+func printPinnedStatus(gitClient *git.DefaultClient, name string, mp marketplace.KnownMarketplace) {
+	fmt.Println(i18n.T("MarketplacePinnedSkip", map[string]any{"Name": name, "Ref": mp.Ref}))
+	if mp.RefKind != string(git.RefTag) {
+		return
+	}
+	tags, err := gitClient.ListRemoteTags(mp.InstallLocation)
+	if err != nil {
+		return
+	}
+	if newer := git.NewerTags(tags, mp.Ref); len(newer) > 0 {
+		fmt.Println(i18n.T("update.newerTags", map[string]any{
+			"Name": name,
+			"Pin":  mp.Ref,
+			"Tags": strings.Join(newer, ", "),
+		}))
+	}
+}
+
+func runMarketplacePin(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	name, ref := args[0], args[1]
+
+	registry := marketplace.GetRegistry()
+	mp, err := registry.Get(name)
+	if err != nil {
+		return err
+	}
+	if mp == nil {
+		return fmt.Errorf("%s", i18n.T("MarketplaceNotFound", map[string]any{"Name": name}))
+	}
+	if mp.Source.Source != marketplace.SourceGit {
+		return fmt.Errorf("only git marketplaces can be pinned (%s is a %s marketplace)", name, mp.Source.Source)
+	}
+
+	gitClient := git.NewClient()
+	kind, err := gitClient.ResolveRef(mp.Source.URL, ref)
+	if err != nil {
+		return err
+	}
+
+	if kind == git.RefBranch {
+		err = gitClient.CheckoutBranch(mp.InstallLocation, ref)
+	} else {
+		err = gitClient.CheckoutRef(mp.InstallLocation, ref)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := registry.SetPin(name, ref, string(kind)); err != nil {
+		return err
+	}
+
+	fmt.Println(i18n.T("MarketplacePinned", map[string]any{"Name": name, "Ref": ref, "Kind": string(kind)}))
+	return nil
+}
+
+func runMarketplaceUnpin(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	name := args[0]
+
+	registry := marketplace.GetRegistry()
+	mp, err := registry.Get(name)
+	if err != nil {
+		return err
+	}
+	if mp == nil {
+		return fmt.Errorf("%s", i18n.T("MarketplaceNotFound", map[string]any{"Name": name}))
+	}
+	if mp.Ref == "" {
+		return fmt.Errorf("marketplace %s is not pinned", name)
+	}
+
+	gitClient := git.NewClient()
+	branch, err := gitClient.GetDefaultBranch(mp.InstallLocation)
+	if err != nil {
+		return err
+	}
+	if err := gitClient.CheckoutBranch(mp.InstallLocation, branch); err != nil {
+		return err
+	}
+
+	if err := registry.SetPin(name, "", ""); err != nil {
+		return err
+	}
+
+	fmt.Println(i18n.T("MarketplaceUnpinned", map[string]any{"Name": name, "Branch": branch}))
+	return nil
+}
+
+func runMarketplaceAuth(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	name := args[0]
+
+	registry := marketplace.GetRegistry()
+	mp, err := registry.Get(name)
+	if err != nil {
+		return err
+	}
+	if mp == nil {
+		return fmt.Errorf("%s", i18n.T("MarketplaceNotFound", map[string]any{"Name": name}))
+	}
+
+	auth := authFromFlags()
+	switch {
+	case marketplaceAuthClear:
+		if err := registry.SetAuth(name, nil); err != nil {
+			return err
+		}
+		fmt.Println(i18n.T("MarketplaceAuthCleared", map[string]any{"Name": name}))
+		return nil
+	case auth == nil:
+		printMarketplaceAuth(name, config.Get().Marketplaces[name].Auth)
+		return nil
+	}
+
+	if mp.Source.Source != marketplace.SourceGit {
+		return fmt.Errorf("credentials can only be configured for git marketplaces (%s is a %s marketplace)", name, mp.Source.Source)
+	}
+
+	// Check the credentials against the remote before saving them
+	gitClient := git.NewClient()
+	gitClient.Auth = auth
+	if _, err := gitClient.GetRemoteRefCommit(mp.Source.URL, ""); err != nil {
+		if authErr, ok := err.(*git.AuthError); ok {
+			return fmt.Errorf("%s", i18n.T("GitAuthFailed", map[string]any{"URL": authErr.URL}))
+		}
+		return err
+	}
+
+	if err := registry.SetAuth(name, auth); err != nil {
+		return err
+	}
+	fmt.Println(i18n.T("MarketplaceAuthSet", map[string]any{"Name": name}))
+	return nil
+}
+
+// printMarketplaceAuth shows the configured credentials of a marketplace
+func printMarketplaceAuth(name string, auth *config.MarketplaceAuth) {
+	if auth == nil {
+		fmt.Printf("%s: no credentials configured\n", name)
+		return
+	}
+	fmt.Printf("%s:\n", name)
+	if auth.TokenEnv != "" {
+		fmt.Printf("  Token: $%s", auth.TokenEnv)
+		if auth.Username != "" {
+			fmt.Printf(" (user %s)", auth.Username)
+		}
+		fmt.Println()
+	}
+	if auth.CredentialHelper != "" {
+		fmt.Printf("  Credential helper: %s\n", auth.CredentialHelper)
+	}
+	if auth.SSHKey != "" {
+		fmt.Printf("  SSH key: %s\n", auth.SSHKey)
+	}
+}
+
+func runMarketplaceMirror(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	name := args[0]
+
+	registry := marketplace.GetRegistry()
+	mp, err := registry.Get(name)
+	if err != nil {
+		return err
+	}
+	if mp == nil {
+		return fmt.Errorf("%s", i18n.T("MarketplaceNotFound", map[string]any{"Name": name}))
+	}
+
+	rewrites, err := rewritesFromFlags()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case marketplaceMirrorClear:
+		if err := registry.SetMirrors(name, nil, nil); err != nil {
+			return err
+		}
+		fmt.Println(i18n.T("MirrorsCleared", map[string]any{"Name": name}))
+		return nil
+	case len(marketplaceMirrors) == 0 && len(rewrites) == 0:
+		printMarketplaceMirrors(name, config.Get().Marketplaces[name])
+		return nil
+	}
+
+	if mp.Source.Source != marketplace.SourceGit {
+		return fmt.Errorf("mirrors can only be configured for git marketplaces (%s is a %s marketplace)", name, mp.Source.Source)
+	}
+
+	if err := registry.SetMirrors(name, marketplaceMirrors, rewrites); err != nil {
+		return err
+	}
+	fmt.Println(i18n.T("MirrorsSet", map[string]any{"Name": name}))
+	return nil
+}
+
+// printMarketplaceMirrors shows the mirror settings of a marketplace
+func printMarketplaceMirrors(name string, mp config.Marketplace) {
+	if len(mp.Mirrors) == 0 && len(mp.Rewrites) == 0 {
+		fmt.Printf("%s: no mirrors configured\n", name)
+		return
+	}
+	fmt.Printf("%s:\n", name)
+	fmt.Printf("  Source: %s\n", mp.Source.URL)
+	for _, mirror := range mp.Mirrors {
+		fmt.Printf("  Mirror: %s\n", mirror)
+	}
+	for _, rule := range mp.Rewrites {
+		fmt.Printf("  Rewrite: %s -> %s\n", rule.From, rule.To)
+	}
+	if mp.ActiveURL != "" {
+		fmt.Printf("  Last used: %s\n", mp.ActiveURL)
+	}
+}
+
+// updateURLMarketplace re-fetches a url marketplace's manifest if it changed
+func updateURLMarketplace(registry *marketplace.Registry, name string, mp marketplace.KnownMarketplace) error {
+	info, _, err := marketplace.NewFetcher().Update(mp.Source.URL, mp.InstallLocation, mp.FetchInfo())
+	if err != nil {
+		return err
+	}
+	return registry.SetFetchInfo(name, info)
+}
+
+func runMarketplaceValidate(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	report := marketplace.Validate(dir)
+
+	if marketplaceValidateJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	} else {
+		for _, p := range report.Problems {
+			fmt.Println(p.String())
+		}
+		if len(report.Problems) > 0 {
+			fmt.Println()
+		}
+		fmt.Println(i18n.T("ValidateSummary", map[string]any{
+			"Plugins":  report.Plugins,
+			"Errors":   report.Errors(),
+			"Warnings": report.Warnings(),
+		}))
+	}
+
+	if n := report.Errors(); n > 0 {
+		return fmt.Errorf("%s", i18n.T("ValidateFailed", map[string]any{"Count": n}))
+	}
+	return nil
+}
+
+func runMarketplaceInit(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	name := marketplaceInitName
+	if name == "" {
+		name = filepath.Base(absDir)
+	}
+	owner := marketplaceInitOwner
+	if owner == "" {
+		owner = defaultAuthorName()
+	}
+
+	if err := marketplace.InitManifest(absDir, name, owner, marketplaceInitDescription); err != nil {
+		return err
+	}
+	fmt.Println(i18n.T("MarketplaceCreated", map[string]any{
+		"Name": name,
+		"Path": filepath.Join(dir, marketplace.ManifestDir, marketplace.ManifestFile),
+	}))
+
+	if marketplaceInitPlugin != "" {
+		manifest, err := marketplace.LoadManifest(absDir)
+		if err != nil {
+			return err
+		}
+		return scaffoldPlugin(marketplaceInitPlugin, filepath.Join(manifest.PluginsDir(absDir), marketplaceInitPlugin))
+	}
+
+	return nil
+}
+
+// defaultAuthorName returns git's user.name, falling back to the login name
+func defaultAuthorName() string {
+	if out, err := exec.Command("git", "config", "user.name").Output(); err == nil {
+		if name := strings.TrimSpace(string(out)); name != "" {
+			return name
+		}
+	}
+	return os.Getenv("USER")
+}
@@ -1,6 +1,9 @@
 package cmd
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -8,6 +11,7 @@ import (
 	"time"
 
 	"github.com/egoavara/codex-market/internal/autoupdate"
+	"github.com/egoavara/codex-market/internal/backup"
 	"github.com/egoavara/codex-market/internal/config"
 	"github.com/egoavara/codex-market/internal/git"
 	"github.com/egoavara/codex-market/internal/i18n"
@@ -29,7 +33,8 @@ Commands:
   uninstall  Uninstall an installed plugin
   update     Update installed plugin(s)
   list       List installed plugins
-  search     Search for plugins`,
+  search     Search for plugins
+  inspect    Show component compatibility with Codex`,
 }
 
 var pluginInstallCmd = &cobra.Command{
@@ -40,8 +45,9 @@ var pluginInstallCmd = &cobra.Command{
 Example:
   codex-market plugin install my-plugin@my-marketplace
   codex-market plugin install my-plugin@my-marketplace -s project`,
-	Args: cobra.ExactArgs(1),
-	RunE: runPluginInstall,
+	Args:        cobra.ExactArgs(1),
+	Annotations: lockState,
+	RunE:        runPluginInstall,
 }
 
 var pluginUninstallCmd = &cobra.Command{
@@ -55,11 +61,16 @@ Scope options:
   -s project  Remove from current project only
   -s all      Remove from all installations
 
+Installed files you edited, and files you added to installed skill folders,
+are listed first; choose to keep them, remove them, or back them up before
+removal. Use --force to remove them without asking.
+
 Example:
   codex-market plugin uninstall my-plugin@my-marketplace
   codex-market plugin uninstall my-plugin@my-marketplace -s all`,
-	Args: cobra.ExactArgs(1),
-	RunE: runPluginUninstall,
+	Args:        cobra.ExactArgs(1),
+	Annotations: lockState,
+	RunE:        runPluginUninstall,
 }
 
 var pluginUsageCmd = &cobra.Command{
@@ -81,14 +92,63 @@ var pluginUpdateCmd = &cobra.Command{
 By default, only updates plugins with version changes.
 Use --force to reinstall all plugins regardless of version.
 
+Installed files you edited are detected before updating. For each, choose to
+keep your version, overwrite it with the update, merge the update into it
+(three-way merge), or back it up and overwrite it. --force overwrites them
+without asking.
+
 Example:
   codex-market plugin update                     # Update plugins with changes
   codex-market plugin update --force             # Force reinstall all plugins
   codex-market plugin update my-plugin@my-marketplace  # Update specific`,
-	RunE: runPluginUpdate,
+	Annotations: lockState,
+	RunE:        runPluginUpdate,
+}
+
+var (
+	pluginUpdateForce    bool
+	pluginUninstallForce bool
+)
+
+var pluginInspectCmd = &cobra.Command{
+	Use:   "inspect <plugin>@<marketplace>",
+	Short: "Show how a plugin's components map onto Codex",
+	Long: `Analyze a plugin without installing it and report each component as
+converted, partial (installed with reduced behavior), or unsupported.
+
+Example:
+  codex-market plugin inspect my-plugin@my-marketplace
+  codex-market plugin inspect my-plugin@my-marketplace --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPluginInspect,
+}
+
+var pluginInspectJSON bool
+
+var pluginNewCmd = &cobra.Command{
+	Use:   "new <name>",
+	Short: "Create a new plugin",
+	Long: `Create a plugin directory with plugin.json, an example skill, an
+example command and optionally an example .mcp.json.
+
+Inside a marketplace the plugin is created in the marketplace's plugin root
+and registered in marketplace.json; elsewhere it is created in the current
+directory.
+
+Example:
+  codex-market plugin new my-plugin
+  codex-market plugin new my-plugin --mcp --description "Does things"
+  codex-market plugin new my-plugin --dir ./somewhere/my-plugin`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPluginNew,
 }
 
-var pluginUpdateForce bool
+var (
+	pluginNewDir         string
+	pluginNewDescription string
+	pluginNewAuthor      string
+	pluginNewMCP         bool
+)
 
 var pluginListCmd = &cobra.Command{
 	Use:   "list",
@@ -113,8 +173,9 @@ The search looks through plugin names, descriptions, tags, and keywords.
 Example:
   codex-market plugin search              # Interactive TUI mode
   codex-market plugin search formatter    # Text search mode`,
-	Args: cobra.MaximumNArgs(1),
-	RunE: runPluginSearch,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: lockState,
+	RunE:        runPluginSearch,
 }
 
 var (
@@ -126,7 +187,13 @@ var (
 func init() {
 	pluginInstallCmd.Flags().StringVarP(&pluginInstallScope, "scope", "s", "global", "install scope (global or project)")
 	pluginUninstallCmd.Flags().StringVarP(&pluginUninstallScope, "scope", "s", "global", "uninstall scope (global, project, or all)")
-	pluginUpdateCmd.Flags().BoolVarP(&pluginUpdateForce, "force", "f", false, "force reinstall regardless of version")
+	pluginUninstallCmd.Flags().BoolVarP(&pluginUninstallForce, "force", "f", false, "remove locally modified files without asking")
+	pluginUpdateCmd.Flags().BoolVarP(&pluginUpdateForce, "force", "f", false, "force reinstall regardless of version, overwriting local changes")
+	pluginInspectCmd.Flags().BoolVar(&pluginInspectJSON, "json", false, "output the report as JSON")
+	pluginNewCmd.Flags().StringVar(&pluginNewDir, "dir", "", "plugin directory (default: <plugin root>/<name> inside a marketplace, ./<name> otherwise)")
+	pluginNewCmd.Flags().StringVar(&pluginNewDescription, "description", "", "plugin description")
+	pluginNewCmd.Flags().StringVar(&pluginNewAuthor, "author", "", "author name (default: git user.name)")
+	pluginNewCmd.Flags().BoolVar(&pluginNewMCP, "mcp", false, "include an example .mcp.json")
 
 	pluginCmd.AddCommand(pluginInstallCmd)
 	pluginCmd.AddCommand(pluginUninstallCmd)
@@ -134,13 +201,39 @@ func init() {
 	pluginCmd.AddCommand(pluginListCmd)
 	pluginCmd.AddCommand(pluginSearchCmd)
 	pluginCmd.AddCommand(pluginUsageCmd)
+	pluginCmd.AddCommand(pluginInspectCmd)
+	pluginCmd.AddCommand(pluginNewCmd)
 }
 
 func runPluginInstall(cmd *cobra.Command, args []string) error {
 	if cmd != nil {
 		cmd.SilenceUsage = true
 	}
-	identifier := args[0]
+
+	// Journal every change so a failed or interrupted install leaves nothing behind
+	tx := plugin.BeginTransaction()
+	defer rollbackTransaction(tx)
+	if err := installPlugin(tx, args[0]); err != nil {
+		return err
+	}
+	tx.Commit()
+	return nil
+}
+
+// failAfter is called after each step of an install or uninstall; tests
+// replace it to make a step fail and check that everything is rolled back
+var failAfter = func(step string) error { return nil }
+
+// rollbackTransaction undoes the changes of a transaction that wasn't committed
+func rollbackTransaction(tx *plugin.Transaction) {
+	if err := tx.Rollback(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: rollback incomplete: %v\n", err)
+	}
+}
+
+// installPlugin installs a plugin, journaling every change in tx. The
+// installed.json entry is only added once everything else succeeded.
+func installPlugin(tx *plugin.Transaction, identifier string) error {
 
 	// Parse plugin identifier
 	pluginName, marketplaceName, err := parsePluginID(identifier)
@@ -155,7 +248,7 @@ func runPluginInstall(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	if mp == nil {
-		return fmt.Errorf(i18n.T("MarketplaceNotFound", map[string]any{"Name": marketplaceName}))
+		return fmt.Errorf("%s", i18n.T("MarketplaceNotFound", map[string]any{"Name": marketplaceName}))
 	}
 
 	// Load marketplace manifest
@@ -167,48 +260,33 @@ func runPluginInstall(cmd *cobra.Command, args []string) error {
 	// Find plugin
 	pluginEntry := manifest.FindPlugin(pluginName)
 	if pluginEntry == nil {
-		return fmt.Errorf(i18n.T("PluginNotFound", map[string]any{
+		return fmt.Errorf("%s", i18n.T("PluginNotFound", map[string]any{
 			"Plugin":      pluginName,
 			"Marketplace": marketplaceName,
 		}))
 	}
 
-	// Get source path
-	sourcePath := manifest.GetPluginSourcePath(mp.InstallLocation, pluginEntry)
-
-	// For remote sources (url, github), clone to temp directory
-	var tempCloneDir string
-	if pluginEntry.IsRemoteSource() {
-		gitClient := git.NewClient()
-		remoteURL := pluginEntry.Source.GetSourceURL()
-
-		// Create temp directory for cloning
-		tempCloneDir, err = os.MkdirTemp("", "codex-plugin-*")
-		if err != nil {
-			return fmt.Errorf("failed to create temp directory: %w", err)
-		}
-		defer os.RemoveAll(tempCloneDir) // Clean up temp directory when done
-
-		if !pluginQuietMode {
-			fmt.Printf("Cloning %s...\n", remoteURL)
-		}
-
-		if err := gitClient.Clone(remoteURL, tempCloneDir); err != nil {
-			return fmt.Errorf("failed to clone plugin repository: %w", err)
-		}
-
-		// Use cloned directory as source path
-		sourcePath = tempCloneDir
-	} else {
-		// Check if source exists (only for local path sources)
-		if _, err := os.Stat(sourcePath); os.IsNotExist(err) {
-			return fmt.Errorf("plugin source not found: %s", sourcePath)
-		}
+	// Prepare plugin source (clones remote sources to a temp directory)
+	source, err := preparePluginSource(mp, manifest, pluginEntry)
+	if errors.Is(err, config.ErrOffline) {
+		source, err = cachedPluginSource(marketplaceName, pluginName)
 	}
+	if err != nil {
+		return err
+	}
+	defer source.Cleanup()
+	sourcePath := source.Path
 
-	// Determine version
+	// Determine version (git plugin sources record the resolved commit,
+	// unversioned archives their checksum)
 	version := pluginEntry.Version
-	if version == "" {
+	if source.CachedVersion != "" {
+		version = source.CachedVersion
+	} else if source.Commit != "" {
+		version = shortVersion(source.Commit)
+	} else if version == "" && source.Checksum != "" {
+		version = shortVersion(source.Checksum)
+	} else if version == "" {
 		gitClient := git.NewClient()
 		commit, err := gitClient.GetCurrentCommit(mp.InstallLocation)
 		if err == nil && len(commit) > 12 {
@@ -230,13 +308,32 @@ func runPluginInstall(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to check installed plugins: %w", err)
 	}
 	if len(existingEntries) > 0 {
-		return fmt.Errorf(i18n.T("AlreadyInstalled", map[string]any{
+		return fmt.Errorf("%s", i18n.T("AlreadyInstalled", map[string]any{
 			"Plugin": pluginID,
 			"Scope":  pluginInstallScope,
 		}))
 	}
 
+	// Resolve component locations from plugin.json and the marketplace entry
+	// (falls back to default folders)
+	components, err := plugin.ResolveComponentsForEntry(sourcePath, pluginEntry.ComponentManifest(), pluginEntry.IsStrict())
+	if err != nil {
+		return err
+	}
+
+	// Merge MCP servers from .mcp.json, custom paths and inline objects
+	mcpServerSet, err := plugin.LoadMCPServers(components)
+	if err != nil {
+		return fmt.Errorf("%s: %w", i18n.T("MCPConfigError", nil), err)
+	}
+
 	if !pluginQuietMode {
+		// Pre-install summary of components that won't fully work under Codex
+		if report, err := plugin.AnalyzeCompatibility(pluginID, components); err == nil && report.HasIssues() {
+			fmt.Println(i18n.T("CompatIssuesHeader", map[string]any{"Plugin": pluginID}))
+			printCompatItems(report, true)
+			fmt.Println()
+		}
 		fmt.Printf("Installing %s...\n", pluginID)
 	}
 
@@ -252,30 +349,39 @@ func runPluginInstall(cmd *cobra.Command, args []string) error {
 		codexSkillsDir = config.CodexSkillsDir()
 	}
 
-	// Find and copy skills from the plugin's skills folder
-	skillsSourceDir := filepath.Join(sourcePath, "skills")
+	// Keep a cache copy of the plugin version; it is the stable plugin root
+	// that ${CLAUDE_PLUGIN_ROOT} expands to in installed files and MCP servers
+	cachePath := filepath.Join(config.PluginCacheDir(), marketplaceName, pluginName, version)
+	if sourcePath != cachePath {
+		// Other installs of this version share the cache; move it aside so a
+		// failed install restores it instead of leaving it half overwritten
+		if err := tx.Remove(cachePath); err != nil {
+			return err
+		}
+	}
+	if err := tx.Created(cachePath); err != nil {
+		return err
+	}
+	if err := config.EnsureDir(cachePath); err != nil {
+		return err
+	}
+	if sourcePath != cachePath {
+		if err := plugin.CopyDir(sourcePath, cachePath); err != nil {
+			return fmt.Errorf("failed to cache plugin files: %w", err)
+		}
+	}
+
+	// Find and copy skills from every resolved skills location
 	var installedSkills []plugin.SkillEntry
 
-	if _, err := os.Stat(skillsSourceDir); err == nil {
-		// Read skills directories
-		skillEntries, err := os.ReadDir(skillsSourceDir)
+	for _, skillsPath := range components.Skills {
+		skillDirs, err := plugin.FindSkillDirs(skillsPath)
 		if err != nil {
-			return fmt.Errorf("failed to read skills folder: %w", err)
+			return err
 		}
 
-		for _, entry := range skillEntries {
-			if !entry.IsDir() {
-				continue
-			}
-
-			skillName := entry.Name()
-			skillSourcePath := filepath.Join(skillsSourceDir, skillName)
-
-			// Check if SKILL.md exists
-			skillMdPath := filepath.Join(skillSourcePath, "SKILL.md")
-			if _, err := os.Stat(skillMdPath); os.IsNotExist(err) {
-				continue // Skip directories without SKILL.md
-			}
+		for _, skillSourcePath := range skillDirs {
+			skillName := filepath.Base(skillSourcePath)
 
 			// Copy skill to Codex skills directory
 			skillDestPath, actualSkillName, err := plugin.ResolveUniqueSkillPath(codexSkillsDir, skillName)
@@ -290,15 +396,21 @@ func runPluginInstall(cmd *cobra.Command, args []string) error {
 				}))
 			}
 
+			if err := tx.Created(skillDestPath); err != nil {
+				return err
+			}
 			if err := config.EnsureDir(skillDestPath); err != nil {
 				return fmt.Errorf("failed to create skill directory: %w", err)
 			}
 
 			if err := plugin.CopyDir(skillSourcePath, skillDestPath); err != nil {
-				os.RemoveAll(skillDestPath)
 				return fmt.Errorf("failed to copy skill files: %w", err)
 			}
 
+			if err := plugin.ExpandPluginRootInDir(skillDestPath, cachePath); err != nil {
+				return fmt.Errorf("failed to rewrite plugin paths in skill %s: %w", actualSkillName, err)
+			}
+
 			installedSkills = append(installedSkills, plugin.SkillEntry{
 				Name: actualSkillName,
 				Path: skillDestPath,
@@ -306,12 +418,15 @@ func runPluginInstall(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Find and copy commands from the plugin's commands folder
-	commandsSourceDir := filepath.Join(sourcePath, "commands")
+	if err := failAfter("skills"); err != nil {
+		return err
+	}
+
+	// Find and copy commands from every resolved commands location
 	var installedCommands []plugin.CommandEntry
 	var codexPromptsDir string
 
-	if _, err := os.Stat(commandsSourceDir); err == nil {
+	if len(components.Commands) > 0 {
 		// Determine Codex prompts directory based on scope
 		if pluginInstallScope == "project" {
 			codexPromptsDir = config.ProjectCodexPromptsDir()
@@ -324,27 +439,24 @@ func runPluginInstall(cmd *cobra.Command, args []string) error {
 		}
 
 		// Ensure prompts directory exists
+		if err := tx.Created(codexPromptsDir); err != nil {
+			return err
+		}
 		if err := config.EnsureDir(codexPromptsDir); err != nil {
 			return fmt.Errorf("failed to create prompts directory: %w", err)
 		}
+	}
 
-		// Read command files
-		commandEntries, err := os.ReadDir(commandsSourceDir)
+	for _, commandsPath := range components.Commands {
+		// Read command files (subfolders become namespaces)
+		commandFiles, err := plugin.FindCommandFiles(commandsPath)
 		if err != nil {
-			return fmt.Errorf("failed to read commands folder: %w", err)
+			return fmt.Errorf("failed to read commands: %w", err)
 		}
 
-		for _, entry := range commandEntries {
-			if entry.IsDir() {
-				continue // Skip directories
-			}
-
-			fileName := entry.Name()
-			if !strings.HasSuffix(fileName, ".md") {
-				continue // Skip non-markdown files
-			}
-
-			commandSourcePath := filepath.Join(commandsSourceDir, fileName)
+		for _, commandFile := range commandFiles {
+			commandSourcePath := commandFile.Path
+			fileName := commandFile.PromptFileName()
 
 			// Resolve unique path (handle conflicts)
 			commandDestPath, actualFileName, err := plugin.ResolveUniquePromptPath(codexPromptsDir, fileName)
@@ -359,96 +471,169 @@ func runPluginInstall(cmd *cobra.Command, args []string) error {
 				}))
 			}
 
-			// Copy command file
-			if err := plugin.CopyFile(commandSourcePath, commandDestPath); err != nil {
+			// Translate command file into Codex prompt format
+			if err := tx.Created(commandDestPath); err != nil {
+				return err
+			}
+			notes, err := plugin.TranslateCommandFile(commandSourcePath, commandDestPath)
+			if err != nil {
 				return fmt.Errorf("failed to copy command file %s: %w", fileName, err)
 			}
+			if !pluginQuietMode {
+				for _, note := range notes {
+					fmt.Println(i18n.T("CommandTranslationNote", map[string]any{
+						"Command": strings.TrimSuffix(actualFileName, ".md"),
+						"Note":    note,
+					}))
+				}
+			}
+
+			if err := plugin.ExpandPluginRootInFile(commandDestPath, cachePath); err != nil {
+				return fmt.Errorf("failed to rewrite plugin paths in command %s: %w", fileName, err)
+			}
 
 			// Command name without .md extension
 			commandName := strings.TrimSuffix(actualFileName, ".md")
 			installedCommands = append(installedCommands, plugin.CommandEntry{
-				Name: commandName,
-				Path: commandDestPath,
+				Name:   commandName,
+				Path:   commandDestPath,
+				Source: commandFile.Name,
 			})
 		}
 	}
 
-	// Find and install MCP servers from .mcp.json
-	mcpJsonPath := filepath.Join(sourcePath, ".mcp.json")
-	var installedMCPServers []plugin.MCPServerEntry
+	if err := failAfter("commands"); err != nil {
+		return err
+	}
 
-	if _, err := os.Stat(mcpJsonPath); err == nil {
-		mcpData, err := os.ReadFile(mcpJsonPath)
+	// Convert agents into Codex skills (Codex has no subagent support)
+	var installedAgents []plugin.AgentEntry
+
+	for _, agentsPath := range components.Agents {
+		agentFiles, err := plugin.FindMarkdownFiles(agentsPath)
 		if err != nil {
-			if !pluginQuietMode {
-				fmt.Printf("Warning: failed to read .mcp.json: %v\n", err)
-			}
-		} else {
-			servers, err := mcp.ParseMCPJSON(mcpData)
+			return fmt.Errorf("failed to read agents: %w", err)
+		}
+
+		for _, agentSourcePath := range agentFiles {
+			agent, err := plugin.LoadAgent(agentSourcePath)
 			if err != nil {
 				if !pluginQuietMode {
-					fmt.Printf("Warning: failed to parse .mcp.json: %v\n", err)
-				}
-			} else if len(servers) > 0 {
-				// Check for conflicts with user-managed servers
-				conflicts, err := mcp.CheckServerNameConflicts(config.CodexConfigPath(), servers)
-				if err != nil && !pluginQuietMode {
-					fmt.Printf("Warning: failed to check MCP server conflicts: %v\n", err)
+					fmt.Printf("Warning: %v\n", err)
 				}
+				continue
+			}
 
-				for _, conflict := range conflicts {
-					if !pluginQuietMode {
-						fmt.Println(i18n.T("MCPServerExists", map[string]any{
-							"Name":    conflict,
-							"Manager": "user",
-						}))
-					}
-					// Remove conflicting server from installation
-					delete(servers, conflict)
-				}
+			skillDestPath, actualSkillName, err := plugin.ResolveUniqueSkillPath(codexSkillsDir, agent.Name)
+			if err != nil {
+				return fmt.Errorf("failed to resolve skill path: %w", err)
+			}
 
-				if len(servers) > 0 {
-					// Add MCP servers to config.toml with markers
-					mismatches, err := mcp.AddMCPServers(config.CodexConfigPath(), pluginName, marketplaceName, servers)
-					if err != nil {
-						if !pluginQuietMode {
-							fmt.Printf("Warning: %s: %v\n", i18n.T("MCPConfigError", nil), err)
-						}
-					} else {
-						for name := range servers {
-							installedMCPServers = append(installedMCPServers, plugin.MCPServerEntry{
-								Name:   name,
-								Plugin: fmt.Sprintf("%s@%s", pluginName, marketplaceName),
-							})
-						}
-						// Warn about env var mismatches
-						if !pluginQuietMode {
-							for _, m := range mismatches {
-								fmt.Println(i18n.T("MCPEnvVarMismatch", map[string]any{
-									"Key":     m.Key,
-									"VarName": m.VarName,
-								}))
-							}
-						}
-					}
-				}
+			if actualSkillName != agent.Name && !pluginQuietMode {
+				fmt.Println(i18n.T("SkillNameConflict", map[string]any{
+					"Original": agent.Name,
+					"Resolved": actualSkillName,
+				}))
+			}
+
+			if err := tx.Created(skillDestPath); err != nil {
+				return err
+			}
+			if err := plugin.ConvertAgentToSkill(agent, pluginID, skillDestPath); err != nil {
+				return fmt.Errorf("failed to convert agent %s: %w", agent.Name, err)
+			}
+
+			if err := plugin.ExpandPluginRootInDir(skillDestPath, cachePath); err != nil {
+				return fmt.Errorf("failed to rewrite plugin paths in agent %s: %w", agent.Name, err)
 			}
+
+			installedAgents = append(installedAgents, plugin.AgentEntry{
+				Name:  agent.Name,
+				Skill: actualSkillName,
+				Path:  skillDestPath,
+			})
 		}
 	}
 
-	// Warn if no skills, commands, or MCP servers found (but continue installation)
-	if len(installedSkills) == 0 && len(installedCommands) == 0 && len(installedMCPServers) == 0 && !pluginQuietMode {
-		fmt.Println("Warning: no skills, commands, or MCP servers found in plugin")
+	if err := failAfter("agents"); err != nil {
+		return err
 	}
 
-	// Also keep a cache copy for tracking
-	cachePath := filepath.Join(config.PluginCacheDir(), marketplaceName, pluginName, version)
-	if err := config.EnsureDir(cachePath); err != nil {
+	// Record what was installed, so local edits can be detected on update and uninstall
+	var installedPaths []string
+	for _, s := range installedSkills {
+		installedPaths = append(installedPaths, s.Path)
+	}
+	for _, c := range installedCommands {
+		installedPaths = append(installedPaths, c.Path)
+	}
+	for _, a := range installedAgents {
+		installedPaths = append(installedPaths, a.Path)
+	}
+	installedFiles, err := plugin.RecordInstalledFiles(tx, installedPaths)
+	if err != nil {
 		return err
 	}
-	if err := plugin.CopyDir(sourcePath, cachePath); err != nil {
-		os.RemoveAll(cachePath)
-		return fmt.Errorf("failed to cache plugin files: %w", err)
+
+	// Install the merged MCP server set
+	var installedMCPServers []plugin.MCPServerEntry
+	servers := mcpServerSet.Servers
+
+	if len(servers) > 0 {
+		// Point ${CLAUDE_PLUGIN_ROOT} at the cached plugin version
+		servers = plugin.ExpandMCPServerRoot(servers, cachePath)
+
+		// Check for conflicts with user-managed servers
+		conflicts, err := mcp.CheckServerNameConflicts(config.CodexConfigPath(), servers)
+		if err != nil && !pluginQuietMode {
+			fmt.Printf("Warning: failed to check MCP server conflicts: %v\n", err)
+		}
+
+		for _, conflict := range conflicts {
+			if !pluginQuietMode {
+				fmt.Println(i18n.T("MCPServerExists", map[string]any{
+					"Name":    conflict,
+					"Manager": "user",
+				}))
+			}
+			// Remove conflicting server from installation
+			delete(servers, conflict)
+		}
+
+		if len(servers) > 0 {
+			// Add MCP servers to config.toml with markers
+			if err := tx.Modified(config.CodexConfigPath()); err != nil {
+				return err
+			}
+			mismatches, err := mcp.AddMCPServers(config.CodexConfigPath(), pluginName, marketplaceName, servers)
+			if err != nil {
+				return fmt.Errorf("%s: %w", i18n.T("MCPConfigError", nil), err)
+			}
+			for name := range servers {
+				installedMCPServers = append(installedMCPServers, plugin.MCPServerEntry{
+					Name:   name,
+					Plugin: fmt.Sprintf("%s@%s", pluginName, marketplaceName),
+				})
+			}
+			// Warn about env var mismatches
+			if !pluginQuietMode {
+				for _, m := range mismatches {
+					fmt.Println(i18n.T("MCPEnvVarMismatch", map[string]any{
+						"Key":     m.Key,
+						"VarName": m.VarName,
+					}))
+				}
+			}
+		}
+	}
+
+	if err := failAfter("mcp"); err != nil {
+		return err
+	}
+
+	// Warn if no skills, commands, agents, or MCP servers found (but continue installation)
+	if len(installedSkills) == 0 && len(installedCommands) == 0 && len(installedAgents) == 0 && len(installedMCPServers) == 0 && !pluginQuietMode {
+		fmt.Println("Warning: no skills, commands, agents, or MCP servers found in plugin")
 	}
 
 	// Add to installed plugins
@@ -462,10 +647,14 @@ func runPluginInstall(cmd *cobra.Command, args []string) error {
 			Marketplace: marketplaceName,
 			URL:         mp.Source.URL,
 			CachePath:   cachePath,
+			Commit:      source.Commit,
+			Checksum:    source.Checksum,
 		},
 		Skills:     installedSkills,
 		Commands:   installedCommands,
+		Agents:     installedAgents,
 		MCPServers: installedMCPServers,
+		Files:      installedFiles,
 	}
 
 	if pluginInstallScope == "project" {
@@ -473,9 +662,17 @@ func runPluginInstall(cmd *cobra.Command, args []string) error {
 		entry.ProjectPath = cwd
 	}
 
+	// installed.json is journaled as well, so a transaction that goes on
+	// after this step (reinstall) can still be rolled back completely
+	if err := tx.Modified(config.InstalledPath()); err != nil {
+		return err
+	}
 	if err := plugin.GetInstalled().Add(pluginID, entry); err != nil {
 		return err
 	}
+	if err := failAfter("installed"); err != nil {
+		return err
+	}
 
 	// Success message
 	if !pluginQuietMode {
@@ -498,11 +695,22 @@ func runPluginInstall(cmd *cobra.Command, args []string) error {
 			commandNames := make([]string, len(installedCommands))
 			for i, c := range installedCommands {
 				commandNames[i] = "/" + c.Name
+				if c.Source != "" && c.Source != c.Name {
+					commandNames[i] += fmt.Sprintf(" (/%s)", c.Source)
+				}
 			}
 			fmt.Printf("  Commands: %s\n", strings.Join(commandNames, ", "))
 			fmt.Printf("  Commands Location: %s\n", codexPromptsDir)
 		}
 
+		if len(installedAgents) > 0 {
+			agentNames := make([]string, len(installedAgents))
+			for i, a := range installedAgents {
+				agentNames[i] = a.Skill
+			}
+			fmt.Printf("  Agents (as skills): %s\n", strings.Join(agentNames, ", "))
+		}
+
 		if len(installedMCPServers) > 0 {
 			mcpNames := make([]string, len(installedMCPServers))
 			for i, m := range installedMCPServers {
@@ -518,9 +726,324 @@ func runPluginInstall(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func runPluginUninstall(cmd *cobra.Command, args []string) error {
+// preparedSource is a local directory containing a plugin's files
+type preparedSource struct {
+	Path          string // plugin root
+	Commit        string // resolved commit (git sources)
+	Checksum      string // SHA-256 of the downloaded archive (archive sources)
+	CachedVersion string // version of the plugin cache the source was taken from (offline mode)
+	cleanup       func()
+}
+
+// Cleanup removes any temp directory the source was prepared in
+func (s *preparedSource) Cleanup() {
+	if s.cleanup != nil {
+		s.cleanup()
+	}
+}
+
+// preparePluginSource returns a local directory containing the plugin files.
+// Remote sources (url, github) are fetched into the persistent repository cache and
+// checked out at the source's sha or ref into a temp directory that is removed by
+// Cleanup; the resolved commit is recorded for them. Archive sources are
+// downloaded, verified and extracted into a temp directory.
+// In offline mode git sources are checked out from the repository cache without
+// fetching; config.ErrOffline is returned when that isn't possible.
+func preparePluginSource(mp *marketplace.KnownMarketplace, manifest *marketplace.MarketplaceManifest, pluginEntry *marketplace.PluginEntry) (*preparedSource, error) {
+	if pluginEntry.IsArchiveSource() {
+		return prepareArchiveSource(mp, manifest, pluginEntry)
+	}
+
+	sourcePath := manifest.GetPluginSourcePath(mp.InstallLocation, pluginEntry)
+
+	if !pluginEntry.IsRemoteSource() {
+		// url marketplaces only store marketplace.json; there is no tree to resolve paths in
+		if mp.Source.Source == marketplace.SourceURL {
+			return nil, fmt.Errorf("plugin %s uses a path source, which is not supported in url marketplaces", pluginEntry.Name)
+		}
+
+		// Check if source exists (only for local path sources)
+		if _, err := os.Stat(sourcePath); os.IsNotExist(err) {
+			return nil, fmt.Errorf("plugin source not found: %s", sourcePath)
+		}
+		return &preparedSource{Path: sourcePath}, nil
+	}
+
+	gitClient := git.NewClient()
+	source := pluginEntry.Source
+	remoteURL := source.GetSourceURL()
+
+	// Keep a bare clone per repository URL and only fetch what changed since last time
+	repoCache := filepath.Join(config.RepoCacheDir(), git.CacheKey(remoteURL))
+
+	if !pluginQuietMode && !config.IsOffline() {
+		if rev := source.Revision(); rev != "" {
+			fmt.Printf("Fetching %s (%s)...\n", remoteURL, rev)
+		} else {
+			fmt.Printf("Fetching %s...\n", remoteURL)
+		}
+	}
+
+	if err := gitClient.UpdateCache(remoteURL, repoCache); err != nil {
+		// Offline, whatever the cache already holds will do
+		if !errors.Is(err, config.ErrOffline) {
+			return nil, fmt.Errorf("failed to fetch plugin repository: %w", err)
+		}
+	}
+
+	// Check out the requested revision into a temp directory
+	tempCloneDir, err := os.MkdirTemp("", "codex-plugin-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	prepared := &preparedSource{cleanup: func() { os.RemoveAll(tempCloneDir) }}
+
+	checkoutDir := filepath.Join(tempCloneDir, "src")
+	if prepared.Commit, err = gitClient.CheckoutCache(repoCache, source.Revision(), checkoutDir); err != nil {
+		prepared.Cleanup()
+		if config.IsOffline() {
+			return nil, config.ErrOffline
+		}
+		return nil, fmt.Errorf("failed to check out plugin repository: %w", err)
+	}
+
+	// Monorepo plugins live in a subfolder of the repository
+	if prepared.Path, err = pluginSubdir(checkoutDir, source.Subdir, remoteURL); err != nil {
+		prepared.Cleanup()
+		return nil, err
+	}
+	return prepared, nil
+}
+
+// prepareArchiveSource downloads (or reads) a plugin archive, verifies its
+// sha256 if the marketplace specifies one, and extracts it into a temp directory
+func prepareArchiveSource(mp *marketplace.KnownMarketplace, manifest *marketplace.MarketplaceManifest, pluginEntry *marketplace.PluginEntry) (*preparedSource, error) {
+	source := pluginEntry.Source
+	location, err := manifest.ArchiveLocation(mp, pluginEntry)
+	if err != nil {
+		return nil, err
+	}
+
+	tempDir, err := os.MkdirTemp("", "codex-plugin-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	prepared := &preparedSource{cleanup: func() { os.RemoveAll(tempDir) }}
+	fail := func(err error) (*preparedSource, error) {
+		prepared.Cleanup()
+		return nil, err
+	}
+
+	archivePath := location
+	if marketplace.IsRemoteArchive(location) {
+		if !pluginQuietMode {
+			fmt.Printf("Downloading %s...\n", location)
+		}
+		archivePath = filepath.Join(tempDir, "archive")
+		prepared.Checksum, err = marketplace.NewFetcher().DownloadArchive(location, archivePath)
+	} else {
+		prepared.Checksum, err = marketplace.HashFile(location)
+		if os.IsNotExist(err) {
+			err = fmt.Errorf("plugin archive not found: %s", location)
+		}
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	if source.SHA256 != "" {
+		if err := marketplace.VerifyChecksum(location, prepared.Checksum, source.SHA256); err != nil {
+			return fail(err)
+		}
+	}
+
+	extractDir := filepath.Join(tempDir, "src")
+	if err := marketplace.ExtractArchive(archivePath, extractDir); err != nil {
+		return fail(fmt.Errorf("failed to extract %s: %w", location, err))
+	}
+
+	if prepared.Path, err = pluginSubdir(marketplace.ArchiveRoot(extractDir), source.Subdir, location); err != nil {
+		return fail(err)
+	}
+	return prepared, nil
+}
+
+// cachedPluginSource returns the most recently cached version tree of a plugin,
+// used when its source can't be fetched in offline mode
+func cachedPluginSource(marketplaceName, pluginName string) (*preparedSource, error) {
+	pluginCache := filepath.Join(config.PluginCacheDir(), marketplaceName, pluginName)
+	entries, err := os.ReadDir(pluginCache)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	var latest string
+	var latestTime time.Time
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || !e.IsDir() {
+			continue
+		}
+		if latest == "" || info.ModTime().After(latestTime) {
+			latest, latestTime = e.Name(), info.ModTime()
+		}
+	}
+	if latest == "" {
+		return nil, fmt.Errorf("%s", i18n.T("OfflineNoCache", map[string]any{
+			"Plugin": fmt.Sprintf("%s@%s", pluginName, marketplaceName),
+		}))
+	}
+
+	prepared := &preparedSource{
+		Path:          filepath.Join(pluginCache, latest),
+		CachedVersion: latest,
+	}
+	// Keep the commit or checksum the cached version was installed from
+	pluginID := fmt.Sprintf("%s@%s", pluginName, marketplaceName)
+	if entries, err := plugin.GetInstalled().Get(pluginID); err == nil {
+		for _, e := range entries {
+			if e.Source.CachePath == prepared.Path {
+				prepared.Commit = e.Source.Commit
+				prepared.Checksum = e.Source.Checksum
+				break
+			}
+		}
+	}
+	if !pluginQuietMode {
+		fmt.Println(i18n.T("OfflineUsingCache", map[string]any{"Version": latest}))
+	}
+	return prepared, nil
+}
+
+// pluginSubdir resolves the plugin folder inside a checked-out repository or
+// extracted archive. An empty subdir means the plugin is at root.
+func pluginSubdir(root, subdir, origin string) (string, error) {
+	if subdir == "" {
+		return root, nil
+	}
+
+	clean := filepath.Clean(filepath.FromSlash(subdir))
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid plugin source path %q: must be relative to the source root", subdir)
+	}
+
+	pluginPath := filepath.Join(root, clean)
+	if info, err := os.Stat(pluginPath); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("plugin source not found: %s in %s", subdir, origin)
+	}
+	return pluginPath, nil
+}
+
+func runPluginInspect(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
 	pluginID := args[0]
 
+	pluginName, marketplaceName, err := parsePluginID(pluginID)
+	if err != nil {
+		return err
+	}
+
+	registry := marketplace.GetRegistry()
+	mp, err := registry.Get(marketplaceName)
+	if err != nil {
+		return err
+	}
+	if mp == nil {
+		return fmt.Errorf("%s", i18n.T("MarketplaceNotFound", map[string]any{"Name": marketplaceName}))
+	}
+
+	manifest, err := marketplace.LoadManifest(mp.InstallLocation)
+	if err != nil {
+		return err
+	}
+
+	pluginEntry := manifest.FindPlugin(pluginName)
+	if pluginEntry == nil {
+		return fmt.Errorf("%s", i18n.T("PluginNotFound", map[string]any{
+			"Plugin":      pluginName,
+			"Marketplace": marketplaceName,
+		}))
+	}
+
+	// Keep clone progress out of machine-readable output
+	pluginQuietMode = pluginInspectJSON
+	defer func() { pluginQuietMode = false }()
+
+	source, err := preparePluginSource(mp, manifest, pluginEntry)
+	if errors.Is(err, config.ErrOffline) {
+		source, err = cachedPluginSource(marketplaceName, pluginName)
+	}
+	if err != nil {
+		return err
+	}
+	defer source.Cleanup()
+	sourcePath := source.Path
+
+	components, err := plugin.ResolveComponentsForEntry(sourcePath, pluginEntry.ComponentManifest(), pluginEntry.IsStrict())
+	if err != nil {
+		return err
+	}
+
+	report, err := plugin.AnalyzeCompatibility(pluginID, components)
+	if err != nil {
+		return err
+	}
+
+	if pluginInspectJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Printf("Plugin: %s\n", pluginID)
+	fmt.Println(strings.Repeat("-", 40))
+	if len(report.Items) == 0 {
+		fmt.Println("  No components found")
+	} else {
+		printCompatItems(report, false)
+	}
+	fmt.Println()
+	fmt.Println(i18n.T("CompatSummary", map[string]any{
+		"Converted":   report.Converted,
+		"Partial":     report.Partial,
+		"Unsupported": report.Unsupported,
+	}))
+
+	return nil
+}
+
+// printCompatItems prints compatibility items, optionally only those with issues
+func printCompatItems(report *plugin.CompatReport, issuesOnly bool) {
+	for _, item := range report.Items {
+		if issuesOnly && item.Status == plugin.CompatConverted {
+			continue
+		}
+		fmt.Printf("  %-13s %-11s %s (%s)\n", "["+string(item.Status)+"]", item.Kind, item.Name, item.Source)
+		for _, reason := range item.Reasons {
+			fmt.Printf("                - %s\n", reason)
+		}
+	}
+}
+
+func runPluginUninstall(cmd *cobra.Command, args []string) error {
+	// Journal every change so a failed or interrupted uninstall can be undone
+	tx := plugin.BeginTransaction()
+	defer rollbackTransaction(tx)
+	if err := uninstallPlugin(tx, args[0]); err != nil {
+		return err
+	}
+	tx.Commit()
+	return nil
+}
+
+// uninstallPlugin removes a plugin, journaling every change in tx. The
+// installed.json entries are only removed once the files are gone; cleanup
+// that can't be undone waits until tx commits.
+func uninstallPlugin(tx *plugin.Transaction, pluginID string) error {
+
 	// Validate scope
 	scope := pluginUninstallScope
 	if scope != "global" && scope != "project" && scope != "all" {
@@ -538,19 +1061,25 @@ func runPluginUninstall(cmd *cobra.Command, args []string) error {
 	}
 	if len(entries) == 0 {
 		if scope == "all" {
-			return fmt.Errorf(i18n.T("NotInstalled", map[string]any{"Plugin": pluginID}))
+			return fmt.Errorf("%s", i18n.T("NotInstalled", map[string]any{"Plugin": pluginID}))
 		}
 		return fmt.Errorf("plugin %s is not installed with scope '%s'", pluginID, scope)
 	}
 
-	// Remove by scope
-	removed, err := installed.RemoveByScope(pluginID, scope, cwd)
-	if err != nil {
-		return err
+	// Ask what to do with files the user changed before removing them
+	var changes []pendingLocalChange
+	if !pluginUninstallForce {
+		for _, entry := range entries {
+			entryChanges, err := decideLocalChanges(pluginID, entry, false)
+			if err != nil {
+				return err
+			}
+			changes = append(changes, entryChanges...)
+		}
 	}
 
-	// Remove skill directories and cache for removed entries
-	for _, entry := range removed {
+	// Remove skill directories, commands and MCP servers of the entries
+	for _, entry := range entries {
 		if !pluginQuietMode {
 			scopeInfo := entry.Scope
 			if entry.Scope == "project" {
@@ -561,26 +1090,46 @@ func runPluginUninstall(cmd *cobra.Command, args []string) error {
 
 		// Remove each skill folder
 		for _, skill := range entry.Skills {
-			if err := os.RemoveAll(skill.Path); err != nil {
-				if !pluginQuietMode {
-					fmt.Printf("  Warning: failed to remove skill %s at %s: %v\n", skill.Name, skill.Path, err)
-				}
-			} else if !pluginQuietMode {
+			if err := tx.Remove(skill.Path); err != nil {
+				return fmt.Errorf("failed to remove skill %s: %w", skill.Name, err)
+			}
+			if !pluginQuietMode {
 				fmt.Printf("  Removed skill: %s (%s)\n", skill.Name, skill.Path)
 			}
 		}
 
+		if err := failAfter("skills"); err != nil {
+			return err
+		}
+
 		// Remove each command file
 		for _, command := range entry.Commands {
-			if err := os.Remove(command.Path); err != nil {
-				if !os.IsNotExist(err) && !pluginQuietMode {
-					fmt.Printf("  Warning: failed to remove command %s at %s: %v\n", command.Name, command.Path, err)
-				}
-			} else if !pluginQuietMode {
+			if err := tx.Remove(command.Path); err != nil {
+				return fmt.Errorf("failed to remove command %s: %w", command.Name, err)
+			}
+			if !pluginQuietMode {
 				fmt.Printf("  Removed command: /%s (%s)\n", command.Name, command.Path)
 			}
 		}
 
+		if err := failAfter("commands"); err != nil {
+			return err
+		}
+
+		// Remove each skill generated from an agent
+		for _, agent := range entry.Agents {
+			if err := tx.Remove(agent.Path); err != nil {
+				return fmt.Errorf("failed to remove agent skill %s: %w", agent.Skill, err)
+			}
+			if !pluginQuietMode {
+				fmt.Printf("  Removed agent skill: %s (%s)\n", agent.Skill, agent.Path)
+			}
+		}
+
+		if err := failAfter("agents"); err != nil {
+			return err
+		}
+
 		// Remove MCP servers from config.toml (by marker)
 		if len(entry.MCPServers) > 0 {
 			// Extract plugin name from pluginID (format: pluginName@marketplace)
@@ -589,12 +1138,13 @@ func runPluginUninstall(cmd *cobra.Command, args []string) error {
 				pluginName = pluginID[:idx]
 			}
 
-			err := mcp.RemoveMCPServers(config.CodexConfigPath(), pluginName)
-			if err != nil {
-				if !pluginQuietMode {
-					fmt.Printf("  Warning: %s: %v\n", i18n.T("MCPConfigError", nil), err)
-				}
-			} else if !pluginQuietMode {
+			if err := tx.Modified(config.CodexConfigPath()); err != nil {
+				return err
+			}
+			if err := mcp.RemoveMCPServers(config.CodexConfigPath(), pluginName); err != nil {
+				return fmt.Errorf("%s: %w", i18n.T("MCPConfigError", nil), err)
+			}
+			if !pluginQuietMode {
 				mcpNames := make([]string, len(entry.MCPServers))
 				for i, m := range entry.MCPServers {
 					mcpNames[i] = m.Name
@@ -604,16 +1154,42 @@ func runPluginUninstall(cmd *cobra.Command, args []string) error {
 				}))
 			}
 		}
+		if err := failAfter("mcp"); err != nil {
+			return err
+		}
+	}
 
-		// Remove cache directory
-		if entry.Source.CachePath != "" {
-			if err := os.RemoveAll(entry.Source.CachePath); err != nil {
-				if !pluginQuietMode {
-					fmt.Printf("  Warning: failed to remove cache %s: %v\n", entry.Source.CachePath, err)
+	// Remove by scope
+	if err := tx.Modified(config.InstalledPath()); err != nil {
+		return err
+	}
+	removed, err := installed.RemoveByScope(pluginID, scope, cwd)
+	if err != nil {
+		return err
+	}
+	if err := failAfter("installed"); err != nil {
+		return err
+	}
+	// Once the outcome is certain, write kept files back and remove cache
+	// directories no other installation uses (installed files and MCP servers
+	// reference them as the plugin root; a reinstall in the same transaction
+	// may still need them). Offline they are kept, as they're the only source
+	// a reinstall can use.
+	tx.OnCommit(func() {
+		applyLocalChanges(changes)
+		for _, entry := range removed {
+			if entry.Source.CachePath != "" && !config.IsOffline() && !isCachePathInUse(entry.Source.CachePath) {
+				if err := os.RemoveAll(entry.Source.CachePath); err != nil {
+					if !pluginQuietMode {
+						fmt.Printf("  Warning: failed to remove cache %s: %v\n", entry.Source.CachePath, err)
+					}
 				}
 			}
 		}
-	}
+		if installedPlugins, err := installed.List(); err == nil {
+			plugin.PruneOriginals(installedPlugins)
+		}
+	})
 
 	// Success message
 	if !pluginQuietMode {
@@ -624,6 +1200,22 @@ func runPluginUninstall(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// isCachePathInUse checks if any remaining installed entry references the cache path
+func isCachePathInUse(cachePath string) bool {
+	installedPlugins, err := plugin.GetInstalled().List()
+	if err != nil {
+		return true // be conservative: keep the cache if state can't be read
+	}
+	for _, entries := range installedPlugins.Plugins {
+		for _, e := range entries {
+			if e.Source.CachePath == cachePath {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func runPluginUsage(cmd *cobra.Command, args []string) error {
 	pluginID := args[0]
 
@@ -634,7 +1226,7 @@ func runPluginUsage(cmd *cobra.Command, args []string) error {
 	}
 
 	if len(entries) == 0 {
-		return fmt.Errorf(i18n.T("NotInstalled", map[string]any{"Plugin": pluginID}))
+		return fmt.Errorf("%s", i18n.T("NotInstalled", map[string]any{"Plugin": pluginID}))
 	}
 
 	fmt.Printf("Plugin: %s\n", pluginID)
@@ -660,6 +1252,12 @@ func runPluginUsage(cmd *cobra.Command, args []string) error {
 				fmt.Printf("      - /%s: %s\n", command.Name, command.Path)
 			}
 		}
+		if len(entry.Agents) > 0 {
+			fmt.Printf("    Agents:\n")
+			for _, agent := range entry.Agents {
+				fmt.Printf("      - %s: %s\n", agent.Name, agent.Path)
+			}
+		}
 		if len(entry.MCPServers) > 0 {
 			fmt.Printf("    MCP Servers:\n")
 			for _, mcpServer := range entry.MCPServers {
@@ -751,14 +1349,23 @@ func runPluginUpdate(cmd *cobra.Command, args []string) error {
 		}
 		fmt.Println()
 
-		// Phase 3: Apply updates with spinner
+		// Phase 3: Apply updates with spinner, asking about local changes first
 		updatedCount := 0
 		for _, item := range toUpdate {
+			var changes []pendingLocalChange
+			if !pluginUpdateForce {
+				if changes, err = decideLocalChanges(item.pluginID, item.entry, true); err != nil {
+					fmt.Printf("  ⚠ %s: %v\n", item.pluginID, err)
+					continue
+				}
+			}
 			spinner := autoupdate.NewSpinner(item.pluginID)
 			spinner.Start()
 			err := reinstallPlugin(item.pluginID, item.entry)
 			spinner.Stop(err == nil)
+			// A failed reinstall was rolled back, local changes included
 			if err == nil {
+				applyLocalChanges(changes)
 				updatedCount++
 			}
 		}
@@ -777,7 +1384,7 @@ func runPluginUpdate(cmd *cobra.Command, args []string) error {
 	// Get installed entry
 	entries, err := installed.Get(pluginID)
 	if err != nil || len(entries) == 0 {
-		return fmt.Errorf(i18n.T("NotInstalled", map[string]any{"Plugin": pluginID}))
+		return fmt.Errorf("%s", i18n.T("NotInstalled", map[string]any{"Plugin": pluginID}))
 	}
 
 	// First update the marketplace
@@ -806,13 +1413,21 @@ func runPluginUpdate(cmd *cobra.Command, args []string) error {
 		}
 		fmt.Println()
 
+		var changes []pendingLocalChange
+		if !pluginUpdateForce {
+			if changes, err = decideLocalChanges(pluginID, entry, true); err != nil {
+				return err
+			}
+		}
 		spinner := autoupdate.NewSpinner(pluginID)
 		spinner.Start()
 		err = reinstallPlugin(pluginID, entry)
 		spinner.Stop(err == nil)
+		// A failed reinstall was rolled back, local changes included
 		if err != nil {
 			return err
 		}
+		applyLocalChanges(changes)
 	}
 
 	return nil
@@ -840,12 +1455,44 @@ func checkPluginNeedsUpdate(pluginID string, entry plugin.InstalledPluginEntry,
 	// Find plugin in manifest
 	pluginEntry := manifest.FindPlugin(pluginName)
 	if pluginEntry == nil {
-		return false, "", fmt.Errorf(i18n.T("PluginNotFound", map[string]any{
+		return false, "", fmt.Errorf("%s", i18n.T("PluginNotFound", map[string]any{
 			"Plugin":      pluginName,
 			"Marketplace": marketplaceName,
 		}))
 	}
 
+	// Git plugin sources are versioned by commit: compare against the pinned
+	// sha, or the commit the ref (or remote HEAD) currently points at
+	if pluginEntry.IsRemoteSource() {
+		remoteCommit := pluginEntry.Source.SHA
+		if remoteCommit == "" {
+			remoteCommit, err = gitClient.GetRemoteRefCommit(pluginEntry.Source.GetSourceURL(), pluginEntry.Source.Ref)
+			if errors.Is(err, config.ErrOffline) {
+				return false, entry.Version, nil
+			}
+			if err != nil {
+				return false, "", err
+			}
+		}
+		installed := entry.Source.Commit
+		if installed == "" {
+			installed = entry.Version
+		}
+		return !git.SameCommit(installed, remoteCommit), shortVersion(remoteCommit), nil
+	}
+
+	// Archives with a published checksum are compared by content
+	if pluginEntry.IsArchiveSource() && pluginEntry.Source.SHA256 != "" && entry.Source.Checksum != "" {
+		if strings.EqualFold(entry.Source.Checksum, pluginEntry.Source.SHA256) {
+			return false, entry.Version, nil
+		}
+		newVersion := pluginEntry.Version
+		if newVersion == "" {
+			newVersion = shortVersion(strings.ToLower(pluginEntry.Source.SHA256))
+		}
+		return true, newVersion, nil
+	}
+
 	// Get new version
 	newVersion := pluginEntry.Version
 	if newVersion == "" {
@@ -861,38 +1508,238 @@ func checkPluginNeedsUpdate(pluginID string, entry plugin.InstalledPluginEntry,
 	return entry.Version != newVersion, newVersion, nil
 }
 
-// reinstallPlugin uninstalls and reinstalls a plugin (quiet mode)
+// shortVersion abbreviates a commit SHA to the length used for plugin versions
+func shortVersion(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
+
+// reinstallPlugin uninstalls and reinstalls a plugin (quiet mode) in one
+// transaction, so the installed version is restored if the reinstall fails.
+// Local changes are overwritten; use decideLocalChanges before and, if it
+// succeeded, applyLocalChanges after it to preserve them.
 func reinstallPlugin(pluginID string, entry plugin.InstalledPluginEntry) error {
-	// Save scope info for reinstall
-	originalScope := entry.Scope
-	originalProjectPath := entry.ProjectPath
+	tx := plugin.BeginTransaction()
+	defer rollbackTransaction(tx)
+	if err := reinstallPluginTx(tx, pluginID, entry); err != nil {
+		return err
+	}
+	tx.Commit()
+	return nil
+}
 
+// reinstallPluginTx is reinstallPlugin as part of a larger transaction
+func reinstallPluginTx(tx *plugin.Transaction, pluginID string, entry plugin.InstalledPluginEntry) error {
 	// Enable quiet mode for batch operation
 	pluginQuietMode = true
-	defer func() { pluginQuietMode = false }()
+	pluginUninstallForce = true
+	defer func() {
+		pluginQuietMode = false
+		pluginUninstallForce = false
+	}()
+
+	// Project scope is resolved from the working directory
+	if entry.Scope == "project" && entry.ProjectPath != "" {
+		oldDir, _ := os.Getwd()
+		if err := os.Chdir(entry.ProjectPath); err != nil {
+			return err
+		}
+		defer os.Chdir(oldDir)
+	}
 
-	// Uninstall
 	pluginUninstallScope = entry.Scope
-	if err := runPluginUninstall(nil, []string{pluginID}); err != nil {
+	if err := uninstallPlugin(tx, pluginID); err != nil {
 		return fmt.Errorf("uninstall failed: %w", err)
 	}
 
-	// Reinstall with same scope
-	pluginInstallScope = originalScope
-	if originalScope == "project" {
-		// Change to project directory for project scope
-		if originalProjectPath != "" {
-			oldDir, _ := os.Getwd()
-			os.Chdir(originalProjectPath)
-			defer os.Chdir(oldDir)
+	pluginInstallScope = entry.Scope
+	if err := installPlugin(tx, pluginID); err != nil {
+		return fmt.Errorf("reinstall failed: %w", err)
+	}
+	return nil
+}
+
+// localChangeAction is what to do with a locally changed file when its plugin
+// is updated or uninstalled
+type localChangeAction int
+
+const (
+	localChangeKeep      localChangeAction = iota // keep the local version
+	localChangeOverwrite                          // replace (update) or remove (uninstall) it
+	localChangeMerge                              // three-way merge the update into it
+	localChangeBackup                             // back it up, then overwrite it
+)
+
+// pendingLocalChange is a local change with the chosen action and the
+// contents needed to carry it out once the plugin's files are replaced
+type pendingLocalChange struct {
+	plugin.LocalChange
+	action localChangeAction
+	local  []byte // contents before the update or uninstall
+	mode   os.FileMode
+	base   []byte // contents as installed, for merging
+}
+
+// decideLocalChanges asks what to do with each file of an installation the
+// user changed. Unless it is overwritten, the local version is backed up right
+// away; keep and merge are carried out by applyLocalChanges after the files
+// were reinstalled or removed.
+func decideLocalChanges(pluginID string, entry plugin.InstalledPluginEntry, updating bool) ([]pendingLocalChange, error) {
+	detected, err := plugin.DetectLocalChanges(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for local changes: %w", err)
+	}
+	if len(detected) == 0 {
+		return nil, nil
+	}
+
+	fmt.Println(i18n.T("LocalChangesFound", map[string]any{"Plugin": pluginID}))
+	reader := bufio.NewReader(os.Stdin)
+	var changes []pendingLocalChange
+	for _, c := range detected {
+		info, err := os.Stat(c.Path)
+		if err != nil {
+			return nil, err
 		}
+		local, err := os.ReadFile(c.Path)
+		if err != nil {
+			return nil, err
+		}
+		change := pendingLocalChange{LocalChange: c, local: local, mode: info.Mode().Perm()}
+
+		// Merging needs the contents as installed, and only makes sense for updates
+		canMerge := false
+		if updating && !c.Added {
+			if base, err := plugin.ReadOriginal(c.Original); err == nil {
+				change.base = base
+				canMerge = true
+			}
+		}
+
+		if c.Added {
+			fmt.Printf("  added:    %s\n", c.Path)
+		} else {
+			fmt.Printf("  modified: %s\n", c.Path)
+		}
+		change.action = promptLocalChangeAction(reader, updating, canMerge)
+
+		// Keep and merge run after the files were replaced, and a failure
+		// there only warns; back the local version up first so it can't be lost
+		if change.action != localChangeOverwrite {
+			if err := backup.Save(c.Path); err != nil {
+				return nil, err
+			}
+		}
+		if change.action == localChangeBackup {
+			fmt.Println(i18n.T("LocalChangeBackedUp", map[string]any{"Path": c.Path}))
+		}
+		changes = append(changes, change)
 	}
+	return changes, nil
+}
 
-	if err := runPluginInstall(nil, []string{pluginID}); err != nil {
-		return fmt.Errorf("reinstall failed: %w", err)
+// promptLocalChangeAction asks for the action for one file. Keep is the
+// default for updates; an uninstall can't keep files (they would be left
+// behind untracked), so it defaults to backing them up. The default is also
+// used when stdin is not interactive.
+func promptLocalChangeAction(reader *bufio.Reader, updating, canMerge bool) localChangeAction {
+	replace := "overwrite"
+	fallback := localChangeKeep
+	options := "[K]eep, [o]verwrite"
+	if canMerge {
+		options += ", [m]erge"
+	}
+	options += ", [b]ackup and overwrite"
+	if !updating {
+		replace = "remove"
+		fallback = localChangeBackup
+		options = "[B]ackup and remove, [r]emove"
 	}
 
-	return nil
+	for {
+		fmt.Printf("    %s? ", options)
+		input, err := reader.ReadString('\n')
+		input = strings.TrimSpace(strings.ToLower(input))
+		if err != nil {
+			fmt.Println()
+		}
+
+		switch {
+		case input == "":
+			return fallback
+		case updating && (input == "k" || input == "keep"):
+			return localChangeKeep
+		case input == replace[:1] || input == replace:
+			return localChangeOverwrite
+		case canMerge && (input == "m" || input == "merge"):
+			return localChangeMerge
+		case input == "b" || input == "backup":
+			return localChangeBackup
+		}
+		if err != nil {
+			return fallback
+		}
+	}
+}
+
+// localChangeBackupHint tells where to find a local version that couldn't be
+// put back
+const localChangeBackupHint = "    Your version was backed up; see 'codex-market backup list'"
+
+// applyLocalChanges carries out keep and merge decisions after the plugin's
+// files were reinstalled or removed
+func applyLocalChanges(changes []pendingLocalChange) {
+	for _, c := range changes {
+		switch c.action {
+		case localChangeKeep:
+			if err := writeLocalChange(c); err != nil {
+				fmt.Printf("  Warning: failed to restore %s: %v\n%s\n", c.Path, err, localChangeBackupHint)
+				continue
+			}
+			fmt.Println(i18n.T("LocalChangeKept", map[string]any{"Path": c.Path}))
+
+		case localChangeMerge:
+			updated, err := os.ReadFile(c.Path)
+			if err != nil {
+				// The update failed or no longer ships the file; there is nothing to merge
+				if err := writeLocalChange(c); err != nil {
+					fmt.Printf("  Warning: failed to restore %s: %v\n%s\n", c.Path, err, localChangeBackupHint)
+					continue
+				}
+				fmt.Println(i18n.T("LocalChangeKept", map[string]any{"Path": c.Path}))
+				continue
+			}
+			merged, conflicts, err := git.MergeFile(c.local, c.base, updated)
+			if err != nil {
+				fmt.Printf("  Warning: failed to merge %s, keeping your version: %v\n", c.Path, err)
+				if err := writeLocalChange(c); err != nil {
+					fmt.Printf("  Warning: failed to restore %s: %v\n%s\n", c.Path, err, localChangeBackupHint)
+				}
+				continue
+			}
+			if err := config.WriteFileAtomic(c.Path, merged, c.mode); err != nil {
+				fmt.Printf("  Warning: failed to write merged %s: %v\n%s\n", c.Path, err, localChangeBackupHint)
+				continue
+			}
+			if conflicts {
+				fmt.Println(i18n.T("LocalChangeConflicts", map[string]any{"Path": c.Path}))
+			} else {
+				fmt.Println(i18n.T("LocalChangeMerged", map[string]any{"Path": c.Path}))
+			}
+		}
+	}
+}
+
+// writeLocalChange puts the local version of a changed file back in place.
+// Directories the update removed are not recreated, so no untracked files are
+// left behind.
+func writeLocalChange(c pendingLocalChange) error {
+	if _, err := os.Stat(filepath.Dir(c.Path)); err != nil {
+		return fmt.Errorf("%s is no longer part of the plugin", filepath.Dir(c.Path))
+	}
+	return config.WriteFileAtomic(c.Path, c.local, c.mode)
 }
 
 func runPluginList(cmd *cobra.Command, args []string) error {
@@ -926,6 +1773,12 @@ func runPluginList(cmd *cobra.Command, args []string) error {
 					fmt.Printf("      - /%s: %s\n", command.Name, command.Path)
 				}
 			}
+			if len(entry.Agents) > 0 {
+				fmt.Printf("    Agents:\n")
+				for _, agent := range entry.Agents {
+					fmt.Printf("      - %s: %s\n", agent.Name, agent.Path)
+				}
+			}
 			fmt.Printf("    Installed: %s\n", entry.InstalledAt)
 			fmt.Println()
 		}
@@ -1055,9 +1908,74 @@ func runTextSearch(manifests map[string]*marketplace.MarketplaceManifest, keywor
 func parsePluginID(identifier string) (string, string, error) {
 	parts := strings.Split(identifier, "@")
 	if len(parts) != 2 {
-		return "", "", fmt.Errorf(i18n.T("InvalidPluginIdentifier", map[string]any{
+		return "", "", fmt.Errorf("%s", i18n.T("InvalidPluginIdentifier", map[string]any{
 			"Identifier": identifier,
 		}))
 	}
 	return parts[0], parts[1], nil
 }
+
+func runPluginNew(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	name := args[0]
+
+	dir := pluginNewDir
+	if dir == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		dir = filepath.Join(cwd, name)
+		if root := marketplace.FindRoot(cwd); root != "" {
+			manifest, err := marketplace.LoadManifest(root)
+			if err != nil {
+				return err
+			}
+			dir = filepath.Join(manifest.PluginsDir(root), name)
+		}
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	return scaffoldPlugin(name, absDir)
+}
+
+// scaffoldPlugin creates a plugin at dir and registers it in the enclosing marketplace, if any
+func scaffoldPlugin(name, dir string) error {
+	author := pluginNewAuthor
+	if author == "" {
+		author = defaultAuthorName()
+	}
+
+	files, err := plugin.Scaffold(dir, plugin.ScaffoldOptions{
+		Name:        name,
+		Description: pluginNewDescription,
+		Author:      author,
+		WithMCP:     pluginNewMCP,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(i18n.T("PluginCreated", map[string]any{"Name": name, "Path": dir}))
+	for _, f := range files {
+		fmt.Printf("  %s\n", f)
+	}
+
+	// Register only when the plugin lives inside a marketplace
+	root := marketplace.FindRoot(filepath.Dir(dir))
+	if root == "" {
+		return nil
+	}
+	if err := marketplace.RegisterPlugin(root, name, pluginNewDescription, dir); err != nil {
+		return err
+	}
+	fmt.Println(i18n.T("PluginRegistered", map[string]any{
+		"Name": name,
+		"Path": filepath.Join(root, marketplace.ManifestDir, marketplace.ManifestFile),
+	}))
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// installSteps are the steps of an install or uninstall a failure is injected after
+var installSteps = []string{"skills", "commands", "agents", "mcp", "installed"}
+
+func TestInstallRollback(t *testing.T) {
+	for _, step := range installSteps {
+		t.Run(step, func(t *testing.T) {
+			home := t.TempDir()
+			newTestMarketplace(t, home, "demo")
+			before := snapshotHome(t, home)
+
+			out, err := runCLI(t, home, []string{"CODEX_MARKET_TEST_FAIL_AFTER=" + step}, "install", "demo@test-market")
+			if err == nil || !strings.Contains(out, "injected failure after "+step) {
+				t.Fatalf("install succeeded or failed elsewhere: %v\n%s", err, out)
+			}
+			if diffs := diffSnapshots(before, snapshotHome(t, home)); len(diffs) > 0 {
+				t.Errorf("install was not rolled back:\n%s\n%s", strings.Join(diffs, "\n"), out)
+			}
+		})
+	}
+}
+
+func TestUninstallRollback(t *testing.T) {
+	for _, step := range installSteps {
+		t.Run(step, func(t *testing.T) {
+			home := t.TempDir()
+			newTestMarketplace(t, home, "demo")
+			if out, err := runCLI(t, home, nil, "install", "demo@test-market"); err != nil {
+				t.Fatalf("install: %v\n%s", err, out)
+			}
+			before := snapshotHome(t, home)
+
+			out, err := runCLI(t, home, []string{"CODEX_MARKET_TEST_FAIL_AFTER=" + step}, "uninstall", "--force", "demo@test-market")
+			if err == nil || !strings.Contains(out, "injected failure after "+step) {
+				t.Fatalf("uninstall succeeded or failed elsewhere: %v\n%s", err, out)
+			}
+			if diffs := diffSnapshots(before, snapshotHome(t, home)); len(diffs) > 0 {
+				t.Errorf("uninstall was not rolled back:\n%s\n%s", strings.Join(diffs, "\n"), out)
+			}
+		})
+	}
+}
+
+func TestInstallRollbackKeepsSharedCache(t *testing.T) {
+	home := t.TempDir()
+	market := newTestMarketplace(t, home, "demo")
+	if out, err := runCLI(t, home, nil, "install", "demo@test-market"); err != nil {
+		t.Fatalf("install: %v\n%s", err, out)
+	}
+
+	// The same version installed in another scope shares the cache directory
+	skill := filepath.Join(market, "plugins", "demo", "skills", "demo-skill", "SKILL.md")
+	if err := os.WriteFile(skill, []byte("---\nname: demo-skill\ndescription: Changed\n---\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	before := snapshotHome(t, home)
+
+	out, err := runCLI(t, home, []string{"CODEX_MARKET_TEST_FAIL_AFTER=skills"}, "install", "--scope", "project", "demo@test-market")
+	if err == nil || !strings.Contains(out, "injected failure after skills") {
+		t.Fatalf("install succeeded or failed elsewhere: %v\n%s", err, out)
+	}
+	if diffs := diffSnapshots(before, snapshotHome(t, home)); len(diffs) > 0 {
+		t.Errorf("shared cache was not restored:\n%s\n%s", strings.Join(diffs, "\n"), out)
+	}
+}
+
+func TestUninstallBacksUpLocalChanges(t *testing.T) {
+	home := t.TempDir()
+	newTestMarketplace(t, home, "demo")
+	if out, err := runCLI(t, home, nil, "install", "demo@test-market"); err != nil {
+		t.Fatalf("install: %v\n%s", err, out)
+	}
+	skill := filepath.Join(home, ".codex", "skills", "demo-skill", "SKILL.md")
+	if err := os.WriteFile(skill, []byte("---\nname: demo-skill\ndescription: Mine\n---\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a terminal the default is used, which must not leave files behind
+	if out, err := runCLI(t, home, nil, "uninstall", "demo@test-market"); err != nil {
+		t.Fatalf("uninstall: %v\n%s", err, out)
+	}
+	if _, err := os.Stat(filepath.Dir(skill)); !os.IsNotExist(err) {
+		t.Errorf("skill directory was left behind: %v", err)
+	}
+	out, err := runCLI(t, home, nil, "backup", "list")
+	if err != nil || !strings.Contains(out, skill) {
+		t.Errorf("changed skill was not backed up: %v\n%s", err, out)
+	}
+}
+
+func TestUpdateBacksUpKeptChanges(t *testing.T) {
+	home := t.TempDir()
+	market := newTestMarketplace(t, home, "demo")
+	if out, err := runCLI(t, home, nil, "install", "demo@test-market"); err != nil {
+		t.Fatalf("install: %v\n%s", err, out)
+	}
+	skill := filepath.Join(home, ".codex", "skills", "demo-skill", "SKILL.md")
+	if err := os.WriteFile(skill, []byte("---\nname: demo-skill\ndescription: Mine\n---\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The new version drops the skill, so the kept version can't be put back
+	if err := os.RemoveAll(filepath.Join(market, "plugins", "demo", "skills")); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, market, map[string]string{
+		".claude-plugin/marketplace.json":         `{"name":"test-market","owner":{"name":"test"},"plugins":[{"name":"demo","source":"./plugins/demo","version":"1.1.0"}]}`,
+		"plugins/demo/.claude-plugin/plugin.json": `{"name":"demo","version":"1.1.0"}`,
+	})
+	if out, err := runCLI(t, home, nil, "marketplace", "update", "test-market"); err != nil {
+		t.Fatalf("marketplace update: %v\n%s", err, out)
+	}
+
+	out, err := runCLI(t, home, nil, "update", "demo@test-market")
+	if err != nil || !strings.Contains(out, "failed to restore") {
+		t.Fatalf("update: %v\n%s", err, out)
+	}
+	if _, err := os.Stat(filepath.Dir(skill)); !os.IsNotExist(err) {
+		t.Errorf("dropped skill directory was recreated: %v", err)
+	}
+	out, err = runCLI(t, home, nil, "backup", "list")
+	if err != nil || !strings.Contains(out, skill) {
+		t.Errorf("kept skill was not backed up: %v\n%s", err, out)
+	}
+}
@@ -3,13 +3,27 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/egoavara/codex-market/internal/config"
+	"github.com/egoavara/codex-market/internal/i18n"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
 
+// stateLockAnnotation marks commands that modify codex-market state. They run
+// holding the cross-process state lock, so concurrent invocations can't lose
+// each other's changes to installed.json, config.json or config.toml.
+const stateLockAnnotation = "codex-market/state-lock"
+
+// lockState is the Annotations value for commands that modify state
+var lockState = map[string]string{stateLockAnnotation: "true"}
+
 var (
-	verbose bool
+	verbose     bool
+	offline     bool
+	lockTimeout time.Duration
+	stateLock   *config.StateLock
 
 	rootCmd = &cobra.Command{
 		Use:           "codex-market",
@@ -26,23 +40,47 @@ Commands:
   plugin       Manage plugins (install, uninstall, update, list, search)
   list         Show all marketplaces and installed plugins
   config       Manage configuration
+  backup       List and restore backups of modified config files
+  doctor       Check installed plugins and repair drift
 
 Shortcuts (aliases):
   install      = plugin install
   uninstall    = plugin uninstall
   search       = plugin search`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if cmd.Annotations[stateLockAnnotation] == "" {
+				return nil
+			}
+			cmd.SilenceUsage = true
+			return acquireStateLock()
+		},
 	}
 )
 
+// acquireStateLock takes the state lock for the rest of the command, waiting
+// for other codex-market processes to finish
+func acquireStateLock() error {
+	var err error
+	stateLock, err = config.AcquireLock(lockTimeout, func(pid int) {
+		if pid > 0 {
+			fmt.Fprintln(os.Stderr, i18n.T("LockWaitingPID", map[string]any{"PID": pid}))
+		} else {
+			fmt.Fprintln(os.Stderr, i18n.T("LockWaiting", nil))
+		}
+	})
+	return err
+}
+
 // createAliasCommand creates a root-level alias that shares flags with a plugin subcommand
 func createAliasCommand(pluginSubCmd *cobra.Command, aliases []string) *cobra.Command {
 	aliasCmd := &cobra.Command{
-		Use:     pluginSubCmd.Use,
-		Short:   pluginSubCmd.Short + " (alias)",
-		Long:    pluginSubCmd.Long,
-		Args:    pluginSubCmd.Args,
-		Aliases: aliases,
-		RunE:    pluginSubCmd.RunE,
+		Use:         pluginSubCmd.Use,
+		Short:       pluginSubCmd.Short + " (alias)",
+		Long:        pluginSubCmd.Long,
+		Args:        pluginSubCmd.Args,
+		Aliases:     aliases,
+		Annotations: pluginSubCmd.Annotations,
+		RunE:        pluginSubCmd.RunE,
 	}
 	// Copy all flags from the original command
 	pluginSubCmd.Flags().VisitAll(func(f *pflag.Flag) {
@@ -53,7 +91,9 @@ func createAliasCommand(pluginSubCmd *cobra.Command, aliases []string) *cobra.Co
 
 // Execute runs the root command
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	stateLock.Release()
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
@@ -61,6 +101,14 @@ func Execute() {
 
 func init() {
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "skip network operations and install from cache")
+	rootCmd.PersistentFlags().DurationVar(&lockTimeout, "lock-timeout", config.DefaultLockTimeout, "how long to wait for another codex-market operation to finish")
+
+	cobra.OnInitialize(func() {
+		if offline {
+			config.SetOffline(true)
+		}
+	})
 
 	// Main commands
 	rootCmd.AddCommand(marketplaceCmd)
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentInstalls(t *testing.T) {
+	const n = 6
+	home := t.TempDir()
+	var names []string
+	for i := 0; i < n; i++ {
+		names = append(names, fmt.Sprintf("p%d", i))
+	}
+	newTestMarketplace(t, home, names...)
+
+	// Each process reads installed.json, config.json and config.toml and
+	// writes them back; without the state lock, installs would be lost
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	outs := make([]string, n)
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			outs[i], errs[i] = runCLI(t, home, nil, "install", name+"@test-market")
+		}()
+	}
+	wg.Wait()
+	for i := range names {
+		if errs[i] != nil {
+			t.Errorf("install %s: %v\n%s", names[i], errs[i], outs[i])
+		}
+	}
+
+	marketDir := filepath.Join(home, ".config", "codex-market")
+	data, err := os.ReadFile(filepath.Join(marketDir, "installed.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var installed struct {
+		Plugins map[string][]json.RawMessage `json:"plugins"`
+	}
+	if err := json.Unmarshal(data, &installed); err != nil {
+		t.Fatalf("installed.json is not valid JSON: %v\n%s", err, data)
+	}
+	for _, name := range names {
+		if len(installed.Plugins[name+"@test-market"]) != 1 {
+			t.Errorf("installed.json has %d entries for %s, want 1", len(installed.Plugins[name+"@test-market"]), name)
+		}
+	}
+
+	data, err = os.ReadFile(filepath.Join(marketDir, "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg map[string]any
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("config.json is not valid JSON: %v\n%s", err, data)
+	}
+
+	toml, err := os.ReadFile(filepath.Join(home, ".codex", "config.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		if !strings.Contains(string(toml), `[mcp_servers."`+name+`-server"]`) {
+			t.Errorf("config.toml is missing the MCP server of %s:\n%s", name, toml)
+		}
+	}
+}
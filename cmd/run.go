@@ -28,6 +28,16 @@ func init() {
 }
 
 func runCodexWrapper(cmd *cobra.Command, args []string) error {
+	// Flag parsing is disabled so codex gets its flags untouched; codex-market's
+	// own flags (e.g., --offline) given before "run" still have to be applied
+	globalArgs, args := splitGlobalArgs(args)
+	if err := rootCmd.PersistentFlags().Parse(globalArgs); err != nil {
+		return err
+	}
+	if offline {
+		config.SetOffline(true)
+	}
+
 	cfg := config.Get()
 
 	// 1. First-time alias setup prompt (TUI)
@@ -67,15 +77,38 @@ func runCodexWrapper(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// 2. Check for updates (if enabled and not disabled mode)
+	// 2. Check for updates (if enabled and not disabled mode), unless another
+	// codex-market process is busy; codex is started either way
 	if cfg.AutoUpdate.Enabled && cfg.AutoUpdate.Mode != config.AutoUpdateModeDisabled {
+		if err := acquireStateLock(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping update check: %v\n", err)
+		} else {
+			runUpdateCheck()
+			stateLock.Release()
+		}
+	}
+
+	// 3. Execute codex with all arguments
+	return execCodex(args)
+}
+
+// runUpdateCheck checks for updates and applies them according to the auto-update mode
+func runUpdateCheck() {
+	cfg := config.Get()
+
+	if !config.IsOffline() {
 		fmt.Println(i18n.T("update.checking", nil))
+	}
 
-		result, err := autoupdate.CheckAll()
-		if err != nil {
-			// Non-fatal: just continue to codex
-			fmt.Fprintf(os.Stderr, "Warning: update check failed: %v\n", err)
-		} else if result.HasAnyUpdate {
+	result, err := autoupdate.CheckAll()
+	if err != nil {
+		// Non-fatal: just continue to codex
+		fmt.Fprintf(os.Stderr, "Warning: update check failed: %v\n", err)
+	} else if result.Offline {
+		fmt.Println(i18n.T("update.offline", nil))
+	} else {
+		autoupdate.ShowPinNotices(result)
+		if result.HasAnyUpdate {
 			if cfg.AutoUpdate.Mode == config.AutoUpdateModeAuto {
 				// Auto mode: apply updates without asking
 				if err := autoupdate.ApplyUpdates(result); err != nil {
@@ -95,11 +128,22 @@ func runCodexWrapper(cmd *cobra.Command, args []string) error {
 		} else {
 			fmt.Println(i18n.T("update.noUpdates", nil))
 		}
-		fmt.Println()
 	}
+	fmt.Println()
+}
 
-	// 3. Execute codex with all arguments
-	return execCodex(args)
+// splitGlobalArgs splits the arguments passed to run into those given before
+// "run" on the command line and those meant for codex
+func splitGlobalArgs(args []string) ([]string, []string) {
+	for i, arg := range os.Args[1:] {
+		if arg == "run" {
+			if i > len(args) {
+				break
+			}
+			return args[:i], args[i:]
+		}
+	}
+	return nil, args
 }
 
 func setupAlias() error {
@@ -1,6 +1,7 @@
 package autoupdate
 
 import (
+	"github.com/egoavara/codex-market/internal/config"
 	"github.com/egoavara/codex-market/internal/git"
 	"github.com/egoavara/codex-market/internal/marketplace"
 	"github.com/egoavara/codex-market/internal/plugin"
@@ -12,12 +13,14 @@ import (
 // Checker handles update checking logic
 type Checker struct {
 	gitClient git.Client
+	fetcher   *marketplace.Fetcher
 }
 
 // NewChecker creates a new update checker
 func NewChecker() *Checker {
 	return &Checker{
 		gitClient: git.NewClient(),
+		fetcher:   marketplace.NewFetcher(),
 	}
 }
 
@@ -35,6 +38,12 @@ func (c *Checker) CheckAll() (*CheckResult, error) {
 		Errors:       []error{},
 	}
 
+	// Nothing can be checked without the network
+	if config.IsOffline() {
+		result.Offline = true
+		return result, nil
+	}
+
 	// Check marketplaces first
 	mpUpdates, mpErrors := c.CheckMarketplaces()
 	result.Marketplaces = mpUpdates
@@ -72,8 +81,27 @@ func (c *Checker) CheckMarketplaces() ([]UpdateInfo, []error) {
 	}
 
 	for name, mp := range marketplaces {
+		// Directory marketplaces are read live; only report manifest changes
+		if mp.Source.Source == marketplace.SourceDirectory {
+			if info, ok := c.checkDirectoryMarketplace(name, mp); ok {
+				updates = append(updates, info)
+			}
+			continue
+		}
+
+		// url marketplaces compare ETags or content hashes instead of commits
+		if mp.Source.Source == marketplace.SourceURL {
+			info, err := c.checkURLMarketplace(name, mp)
+			if err != nil {
+				errors = append(errors, err)
+				continue
+			}
+			updates = append(updates, info)
+			continue
+		}
+
 		// Only check git-based marketplaces
-		if mp.Source.Source != "git" {
+		if mp.Source.Source != marketplace.SourceGit {
 			continue
 		}
 
@@ -83,6 +111,22 @@ func (c *Checker) CheckMarketplaces() ([]UpdateInfo, []error) {
 			Path: mp.InstallLocation,
 		}
 
+		// Tag and commit pins never move; tag pins report newer tags instead
+		if mp.IsPinnedToRevision() {
+			info.Pin = mp.Ref
+			info.CurrentVer = mp.Ref
+			if mp.RefKind == string(git.RefTag) {
+				tags, err := c.gitClient.ListRemoteTags(mp.InstallLocation)
+				if err != nil {
+					errors = append(errors, err)
+					continue
+				}
+				info.NewerTags = git.NewerTags(tags, mp.Ref)
+			}
+			updates = append(updates, info)
+			continue
+		}
+
 		// Get current commit
 		currentCommit, err := c.gitClient.GetCurrentCommit(mp.InstallLocation)
 		if err != nil {
@@ -113,6 +157,51 @@ func (c *Checker) CheckMarketplaces() ([]UpdateInfo, []error) {
 	return updates, errors
 }
 
+// checkDirectoryMarketplace compares a directory marketplace's manifest with the
+// hash recorded at its last update. Returns false if there is no recorded hash.
+func (c *Checker) checkDirectoryMarketplace(name string, mp marketplace.KnownMarketplace) (UpdateInfo, bool) {
+	if mp.ManifestHash == "" {
+		return UpdateInfo{}, false
+	}
+
+	info := UpdateInfo{
+		Type:       UpdateTypeMarketplace,
+		Name:       name,
+		Path:       mp.InstallLocation,
+		CurrentVer: shortCommit(mp.ManifestHash),
+	}
+
+	hash, err := marketplace.ManifestHash(mp.InstallLocation)
+	if err != nil || hash == mp.ManifestHash {
+		return info, true
+	}
+
+	info.RemoteVer = shortCommit(hash)
+	info.HasUpdate = true
+	return info, true
+}
+
+// checkURLMarketplace asks the server whether a url marketplace's manifest changed
+func (c *Checker) checkURLMarketplace(name string, mp marketplace.KnownMarketplace) (UpdateInfo, error) {
+	info := UpdateInfo{
+		Type:       UpdateTypeMarketplace,
+		Name:       name,
+		Path:       mp.InstallLocation,
+		CurrentVer: shortCommit(mp.ManifestHash),
+	}
+
+	hasUpdate, fetched, err := c.fetcher.HasUpdate(mp.Source.URL, mp.FetchInfo())
+	if err != nil {
+		return info, err
+	}
+	if hasUpdate {
+		info.RemoteVer = shortCommit(fetched.Hash)
+		info.HasUpdate = true
+	}
+
+	return info, nil
+}
+
 // CheckPlugins checks for updates in all installed plugins
 // updatedMarketplaces contains marketplaces that have pending updates
 func (c *Checker) CheckPlugins(updatedMarketplaces map[string]bool) ([]UpdateInfo, []error) {
@@ -126,6 +215,7 @@ func (c *Checker) CheckPlugins(updatedMarketplaces map[string]bool) ([]UpdateInf
 		return updates, errors
 	}
 	plugins := installedPlugins.Plugins
+	manifests := make(map[string]*marketplace.MarketplaceManifest)
 
 	for pluginID, entries := range plugins {
 		for _, entry := range entries {
@@ -136,6 +226,21 @@ func (c *Checker) CheckPlugins(updatedMarketplaces map[string]bool) ([]UpdateInf
 				Path:       entry.Source.CachePath,
 			}
 
+			// Git plugin sources are compared against their own remote, not the marketplace
+			if entry.Source.Commit != "" {
+				remoteCommit, err := c.remotePluginCommit(pluginID, entry, manifests)
+				if err != nil {
+					errors = append(errors, err)
+					continue
+				}
+				if remoteCommit != "" && !git.SameCommit(entry.Source.Commit, remoteCommit) {
+					info.HasUpdate = true
+					info.RemoteVer = shortCommit(remoteCommit)
+					updates = append(updates, info)
+				}
+				continue
+			}
+
 			// If the marketplace has updates, the plugin also needs update
 			if updatedMarketplaces[entry.Source.Marketplace] {
 				info.HasUpdate = true
@@ -152,6 +257,33 @@ func (c *Checker) CheckPlugins(updatedMarketplaces map[string]bool) ([]UpdateInf
 	return updates, errors
 }
 
+// remotePluginCommit returns the commit a git plugin source currently resolves to:
+// its pinned sha, or the remote commit of its ref (or HEAD).
+// Returns "" if the plugin no longer has a git source in its marketplace.
+func (c *Checker) remotePluginCommit(pluginID string, entry plugin.InstalledPluginEntry, manifests map[string]*marketplace.MarketplaceManifest) (string, error) {
+	mpName := entry.Source.Marketplace
+	manifest, ok := manifests[mpName]
+	if !ok {
+		mp, err := marketplace.GetRegistry().Get(mpName)
+		if err != nil || mp == nil {
+			return "", err
+		}
+		if manifest, err = marketplace.LoadManifest(mp.InstallLocation); err != nil {
+			return "", err
+		}
+		manifests[mpName] = manifest
+	}
+
+	pluginEntry := manifest.FindPlugin(extractPluginName(pluginID))
+	if pluginEntry == nil || !pluginEntry.IsRemoteSource() {
+		return "", nil
+	}
+	if pluginEntry.Source.SHA != "" {
+		return pluginEntry.Source.SHA, nil
+	}
+	return c.gitClient.GetRemoteRefCommit(pluginEntry.Source.GetSourceURL(), pluginEntry.Source.Ref)
+}
+
 // shortCommit returns first 7 characters of a commit hash
 func shortCommit(commit string) string {
 	if len(commit) > 7 {
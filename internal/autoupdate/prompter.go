@@ -47,6 +47,20 @@ func ShowUpdateSummary(result *CheckResult) {
 	fmt.Println()
 }
 
+// ShowPinNotices lists marketplaces pinned to a tag for which newer tags exist
+func ShowPinNotices(result *CheckResult) {
+	for _, mp := range result.Marketplaces {
+		if len(mp.NewerTags) == 0 {
+			continue
+		}
+		fmt.Println(i18n.T("update.newerTags", map[string]any{
+			"Name": mp.Name,
+			"Pin":  mp.Pin,
+			"Tags": strings.Join(mp.NewerTags, ", "),
+		}))
+	}
+}
+
 // PromptUpdate asks the user if they want to apply updates
 func PromptUpdate(result *CheckResult) bool {
 	if !result.HasAnyUpdate {
@@ -16,6 +16,8 @@ type UpdateInfo struct {
 	RemoteVer  string     // Remote version/commit
 	HasUpdate  bool       // Whether update is available
 	Path       string     // Path to the item (for marketplace) or plugin ID
+	Pin        string     // Pinned tag or commit (marketplaces), if any
+	NewerTags  []string   // Tags newer than a pinned tag, newest first
 }
 
 // CheckResult contains the result of update check
@@ -23,6 +25,7 @@ type CheckResult struct {
 	Marketplaces []UpdateInfo
 	Plugins      []UpdateInfo
 	HasAnyUpdate bool
+	Offline      bool    // Check was skipped because of offline mode
 	Errors       []error // Non-fatal errors during check
 }
 
@@ -66,12 +66,14 @@ func (s *Spinner) Stop(success bool) {
 // Updater handles applying updates
 type Updater struct {
 	gitClient git.Client
+	fetcher   *marketplace.Fetcher
 }
 
 // NewUpdater creates a new updater
 func NewUpdater() *Updater {
 	return &Updater{
 		gitClient: git.NewClient(),
+		fetcher:   marketplace.NewFetcher(),
 	}
 }
 
@@ -139,13 +141,28 @@ func (u *Updater) ApplyUpdates(result *CheckResult) error {
 
 // updateMarketplace pulls the latest changes for a marketplace
 func (u *Updater) updateMarketplace(info UpdateInfo) error {
-	// Pull latest changes
-	if err := u.gitClient.Pull(info.Path); err != nil {
-		return fmt.Errorf("failed to update marketplace: %w", err)
+	registry := marketplace.GetRegistry()
+
+	mp, _ := registry.Get(info.Name)
+
+	// url marketplaces re-download marketplace.json and record the new validators
+	if mp != nil && mp.Source.Source == marketplace.SourceURL {
+		fetched, _, err := u.fetcher.Update(mp.Source.URL, mp.InstallLocation, mp.FetchInfo())
+		if err != nil {
+			return fmt.Errorf("failed to update marketplace: %w", err)
+		}
+		return registry.SetFetchInfo(info.Name, fetched)
+	}
+
+	// Directory marketplaces are used in place, and tag/commit pins never move
+	if mp == nil || (mp.Source.Source != marketplace.SourceDirectory && !mp.IsPinnedToRevision()) {
+		// Pull latest changes
+		if err := u.gitClient.Pull(info.Path); err != nil {
+			return fmt.Errorf("failed to update marketplace: %w", err)
+		}
 	}
 
 	// Update timestamp in registry
-	registry := marketplace.GetRegistry()
 	if err := registry.UpdateTimestamp(info.Name); err != nil {
 		// Non-fatal, just log
 		return nil
@@ -0,0 +1,217 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/egoavara/codex-market/internal/config"
+)
+
+// MaxBackups is how many backups are kept per file; older ones are deleted
+const MaxBackups = 10
+
+// idFormat names backups by their UTC creation time
+const idFormat = "20060102-150405.000"
+
+// originFile records the original path in each file's backup directory
+const originFile = "path"
+
+// Backup is a saved copy of a file
+type Backup struct {
+	ID      string    // creation time, e.g. "20261016-100512.123"
+	Path    string    // location of the backup copy
+	Size    int64     // size in bytes
+	Created time.Time // creation time
+}
+
+// WriteFile replaces the contents of a file codex-market doesn't own (e.g.,
+// ~/.codex/config.toml or Claude's settings.json). The current contents are
+// backed up first and the new contents are written atomically.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	if err := Save(path); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	return config.WriteFileAtomic(path, data, perm)
+}
+
+// Save backs up the current contents of a file, keeping the newest MaxBackups
+// copies. Nothing is saved if the file doesn't exist or is unchanged since the
+// last backup.
+func Save(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	backups, err := List(path)
+	if err != nil {
+		return err
+	}
+	if len(backups) > 0 {
+		if latest, err := os.ReadFile(backups[0].Path); err == nil && bytes.Equal(latest, data) {
+			return nil
+		}
+	}
+
+	dir := fileDir(path)
+	if err := config.EnsureDir(dir); err != nil {
+		return err
+	}
+	if err := config.WriteFileAtomic(filepath.Join(dir, originFile), []byte(path+"\n"), 0644); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	id := now.Format(idFormat)
+	for i := 1; fileExists(filepath.Join(dir, id)); i++ {
+		id = fmt.Sprintf("%s-%d", now.Format(idFormat), i)
+	}
+	if err := config.WriteFileAtomic(filepath.Join(dir, id), data, 0600); err != nil {
+		return err
+	}
+
+	// Rotate: List returns newest first
+	backups, err = List(path)
+	if err != nil {
+		return err
+	}
+	for _, b := range backups[min(len(backups), MaxBackups):] {
+		os.Remove(b.Path)
+	}
+	return nil
+}
+
+// List returns the backups of a file, newest first
+func List(path string) ([]Backup, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	dir := fileDir(path)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []Backup
+	for _, e := range entries {
+		if e.IsDir() || e.Name() == originFile {
+			continue
+		}
+		// IDs made within the same millisecond get a "-N" suffix
+		created, err := time.Parse(idFormat, e.Name()[:min(len(e.Name()), len(idFormat))])
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{
+			ID:      e.Name(),
+			Path:    filepath.Join(dir, e.Name()),
+			Size:    info.Size(),
+			Created: created,
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].ID > backups[j].ID
+	})
+	return backups, nil
+}
+
+// Files returns the original paths of all files that have backups, sorted
+func Files() ([]string, error) {
+	entries, err := os.ReadDir(config.BackupDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(config.BackupDir(), e.Name(), originFile))
+		if err != nil {
+			continue
+		}
+		files = append(files, strings.TrimSpace(string(data)))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Restore replaces a file with one of its backups. An empty id restores the
+// newest backup. The contents being replaced are backed up in turn, so a
+// restore can itself be undone.
+func Restore(path, id string) (*Backup, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	backups, err := List(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(backups) == 0 {
+		return nil, fmt.Errorf("no backups of %s", path)
+	}
+
+	target := &backups[0]
+	if id != "" {
+		target = nil
+		for i := range backups {
+			if backups[i].ID == id {
+				target = &backups[i]
+				break
+			}
+		}
+		if target == nil {
+			return nil, fmt.Errorf("backup %s of %s not found", id, path)
+		}
+	}
+
+	data, err := os.ReadFile(target.Path)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.EnsureDir(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	if err := WriteFile(path, data, 0644); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// fileDir returns the backup directory of a file: its base name plus a hash
+// of the full path, so files with the same name don't collide
+func fileDir(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	name := strings.TrimPrefix(filepath.Base(path), ".")
+	return filepath.Join(config.BackupDir(), name+"-"+hex.EncodeToString(sum[:])[:12])
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
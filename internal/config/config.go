@@ -2,7 +2,10 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
+	"sort"
+	"strings"
 	"sync"
 )
 
@@ -39,10 +42,12 @@ type AutoUpdateConfig struct {
 
 // Config represents the main configuration file structure
 type Config struct {
+	Version      int                    `json:"version"`    // schema version, see configMigrations
 	Locale       string                 `json:"locale"`     // "auto" or ISO format (e.g., "ko-KR", "en-US")
 	AutoUpdate   AutoUpdateConfig       `json:"autoUpdate"` // Auto-update settings
 	Claude       ClaudeConfig           `json:"claude"`
 	Marketplaces map[string]Marketplace `json:"marketplaces"`
+	Offline      bool                   `json:"offline,omitempty"` // Skip network operations and install from cache
 }
 
 // ClaudeConfig contains Claude-related settings
@@ -60,11 +65,45 @@ type Marketplace struct {
 	Source          MarketplaceSource `json:"source"`
 	InstallLocation string            `json:"installLocation"`
 	LastUpdated     string            `json:"lastUpdated"`
+	ManifestHash    string            `json:"manifestHash,omitempty"` // marketplace.json hash at last update (directory and url sources)
+	ETag            string            `json:"etag,omitempty"`         // HTTP ETag of the manifest (url sources)
+	LastModified    string            `json:"lastModified,omitempty"` // HTTP Last-Modified of the manifest (url sources)
+	Ref             string            `json:"ref,omitempty"`          // pinned branch, tag or commit (git sources)
+	RefKind         string            `json:"refKind,omitempty"`      // "branch", "tag" or "commit"
+	Auth            *MarketplaceAuth  `json:"auth,omitempty"`         // credentials for private repositories (git sources)
+	Mirrors         []string          `json:"mirrors,omitempty"`      // fallback URLs tried in order when the source URL fails (git sources)
+	Rewrites        []HostRewrite     `json:"rewrites,omitempty"`     // host rewrite rules, also applied to github plugin sources
+	ActiveURL       string            `json:"activeUrl,omitempty"`    // URL (source or mirror) of the last successful clone or fetch
+}
+
+// HostRewrite replaces the host of a git remote URL, e.g. github.com -> git.corp.example
+type HostRewrite struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Apply returns url with its host rewritten, or "" if the rule doesn't match
+func (r HostRewrite) Apply(url string) string {
+	host := RemoteHost(url)
+	if host == "" || host != strings.ToLower(r.From) {
+		return ""
+	}
+	i := strings.Index(strings.ToLower(url), host)
+	return url[:i] + r.To + url[i+len(host):]
+}
+
+// MarketplaceAuth holds credentials for a marketplace's git operations.
+// They are also used for remote plugin sources hosted on the same host.
+type MarketplaceAuth struct {
+	TokenEnv         string `json:"tokenEnv,omitempty"`         // environment variable holding an HTTPS access token
+	Username         string `json:"username,omitempty"`         // user name sent with the token (default: x-access-token)
+	CredentialHelper string `json:"credentialHelper,omitempty"` // git credential helper, e.g. "store" or "!gh auth git-credential"
+	SSHKey           string `json:"sshKey,omitempty"`           // private key file for SSH remotes
 }
 
 // MarketplaceSource describes the source of a marketplace
 type MarketplaceSource struct {
-	Source string `json:"source"` // "git", "directory"
+	Source string `json:"source"` // "git", "directory", "url"
 	URL    string `json:"url,omitempty"`
 	Path   string `json:"path,omitempty"`
 }
@@ -73,12 +112,40 @@ var (
 	cfg     *Config
 	cfgOnce sync.Once
 	cfgMu   sync.RWMutex
+
+	// offlineOverride is set by the --offline flag for the current process
+	offlineOverride bool
 )
 
+// ErrOffline is returned by network operations attempted in offline mode
+var ErrOffline = errors.New("network access is disabled in offline mode")
+
+// configMigrations upgrade config.json one schema version at a time;
+// configMigrations[i] upgrades version i to i+1
+var configMigrations = []Migration{
+	// 0 -> 1: files from before schema versioning. Older versions filled in
+	// missing settings on every load; store the defaults instead. A missing
+	// autoUpdate section means auto-update was never turned off.
+	func(doc map[string]any) error {
+		setDefault(doc, "locale", "auto")
+		if _, ok := doc["autoUpdate"]; !ok {
+			doc["autoUpdate"] = map[string]any{"enabled": true}
+		}
+		setDefault(objectField(doc, "autoUpdate"), "mode", string(AutoUpdateModeNotify))
+		setDefault(objectField(objectField(doc, "claude"), "registry"), "share", string(ShareIgnore))
+		objectField(doc, "marketplaces")
+		return nil
+	},
+}
+
+// ConfigVersion is the schema version of config.json written by this version
+var ConfigVersion = len(configMigrations)
+
 // NewConfig creates a new Config with default values
 func NewConfig() *Config {
 	return &Config{
-		Locale: "auto", // default: auto-detect system locale
+		Version: ConfigVersion,
+		Locale:  "auto", // default: auto-detect system locale
 		AutoUpdate: AutoUpdateConfig{
 			Enabled:              true,               // default: enabled
 			Mode:                 AutoUpdateModeNotify, // default: notify user
@@ -106,6 +173,12 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	// Upgrade files written by older versions
+	data, err = MigrateJSON(ConfigPath(), data, configMigrations)
+	if err != nil {
+		return nil, err
+	}
+
 	var config Config
 	if err := json.Unmarshal(data, &config); err != nil {
 		return nil, err
@@ -116,21 +189,6 @@ func Load() (*Config, error) {
 		config.Marketplaces = make(map[string]Marketplace)
 	}
 
-	// Set default share mode if empty
-	if config.Claude.Registry.Share == "" {
-		config.Claude.Registry.Share = ShareIgnore
-	}
-
-	// Set default locale if empty
-	if config.Locale == "" {
-		config.Locale = "auto"
-	}
-
-	// Set default auto-update mode if empty
-	if config.AutoUpdate.Mode == "" {
-		config.AutoUpdate.Mode = AutoUpdateModeNotify
-	}
-
 	return &config, nil
 }
 
@@ -143,12 +201,18 @@ func Save(config *Config) error {
 		return err
 	}
 
+	// Never drop what a newer codex-market stored; keep files of an older one
+	if err := PrepareWrite(ConfigPath(), ConfigVersion); err != nil {
+		return err
+	}
+	config.Version = ConfigVersion
+
 	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(ConfigPath(), data, 0644)
+	return WriteFileAtomic(ConfigPath(), data, 0644)
 }
 
 // Get returns the current configuration (singleton)
@@ -165,13 +229,14 @@ func Get() *Config {
 
 // Reload reloads the configuration from file
 func Reload() error {
-	cfgMu.Lock()
-	defer cfgMu.Unlock()
-
+	// Load takes the read lock itself
 	newCfg, err := Load()
 	if err != nil {
 		return err
 	}
+
+	cfgMu.Lock()
+	defer cfgMu.Unlock()
 	cfg = newCfg
 	return nil
 }
@@ -188,6 +253,17 @@ func SetShareMode(mode ShareMode) error {
 	return Save(config)
 }
 
+// SetOffline forces offline mode for the current process without saving it
+func SetOffline(offline bool) {
+	offlineOverride = offline
+}
+
+// IsOffline reports whether network operations should be skipped,
+// either because of the --offline flag or the offline config option
+func IsOffline() bool {
+	return offlineOverride || Get().Offline
+}
+
 // GetLocale returns the configured locale
 func GetLocale() string {
 	return Get().Locale
@@ -199,3 +275,166 @@ func SetLocale(locale string) error {
 	config.Locale = locale
 	return Save(config)
 }
+
+// AuthFor returns the credentials to use for a git remote URL: those of the
+// marketplace cloned from that URL (or one of its mirrors), else those of a
+// marketplace on the same host. Returns nil if none apply.
+func (c *Config) AuthFor(url string) *MarketplaceAuth {
+	if !c.HasAuth() {
+		return nil
+	}
+	if _, mp, ok := c.marketplaceForRemote(url); ok && mp.Auth != nil {
+		return mp.Auth
+	}
+
+	host := RemoteHost(url)
+	if host == "" {
+		return nil
+	}
+	for _, name := range c.sortedMarketplaceNames() {
+		mp := c.Marketplaces[name]
+		if mp.Auth != nil && RemoteHost(mp.Source.URL) == host {
+			return mp.Auth
+		}
+	}
+	return nil
+}
+
+// HasAuth reports whether any marketplace has credentials configured
+func (c *Config) HasAuth() bool {
+	for _, mp := range c.Marketplaces {
+		if mp.Auth != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoteHost returns the lowercase host of a git remote URL
+// ("https://host/...", "ssh://user@host:22/...", or scp-like "user@host:path").
+// Returns "" for local paths and file:// URLs.
+func RemoteHost(url string) string {
+	if i := strings.Index(url, "://"); i >= 0 {
+		if url[:i] == "file" {
+			return ""
+		}
+		rest := url[i+3:]
+		if j := strings.IndexByte(rest, '/'); j >= 0 {
+			rest = rest[:j]
+		}
+		if j := strings.LastIndexByte(rest, '@'); j >= 0 {
+			rest = rest[j+1:]
+		}
+		if j := strings.LastIndexByte(rest, ':'); j >= 0 {
+			rest = rest[:j]
+		}
+		return strings.ToLower(rest)
+	}
+
+	// scp-like syntax: [user@]host:path
+	if i := strings.IndexByte(url, ':'); i > 0 && !strings.ContainsAny(url[:i], "/\\") {
+		host := url[:i]
+		if j := strings.LastIndexByte(host, '@'); j >= 0 {
+			host = host[j+1:]
+		}
+		// A single letter is a Windows drive, not a host
+		if len(host) > 1 {
+			return strings.ToLower(host)
+		}
+	}
+	return ""
+}
+
+// RemoteURLs returns the URLs to try, in order, for a git remote. For a
+// marketplace (matched by its source, mirror or active URL) these are the URL
+// that last succeeded, the source URL, its mirrors and its host rewrites.
+// Other remotes, such as plugin sources, get the host rewrites of every marketplace.
+func (c *Config) RemoteURLs(url string) []string {
+	var urls []string
+	seen := make(map[string]bool)
+	add := func(u string) {
+		if u != "" && !seen[normalizeRemote(u)] {
+			seen[normalizeRemote(u)] = true
+			urls = append(urls, u)
+		}
+	}
+
+	if _, mp, ok := c.marketplaceForRemote(url); ok {
+		add(mp.ActiveURL)
+		add(mp.Source.URL)
+		for _, mirror := range mp.Mirrors {
+			add(mirror)
+		}
+		for _, rule := range mp.Rewrites {
+			add(rule.Apply(mp.Source.URL))
+		}
+		return urls
+	}
+
+	add(url)
+	for _, name := range c.sortedMarketplaceNames() {
+		for _, rule := range c.Marketplaces[name].Rewrites {
+			add(rule.Apply(url))
+		}
+	}
+	return urls
+}
+
+// HasMirrors reports whether any marketplace has mirrors or host rewrites configured
+func (c *Config) HasMirrors() bool {
+	for _, mp := range c.Marketplaces {
+		if len(mp.Mirrors) > 0 || len(mp.Rewrites) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordActiveURL remembers which of a marketplace's URLs last succeeded.
+// Does nothing if url doesn't belong to a marketplace or nothing changed.
+func RecordActiveURL(url, active string) error {
+	cfg := Get()
+	name, mp, ok := cfg.marketplaceForRemote(url)
+	if !ok || mp.ActiveURL == active {
+		return nil
+	}
+	mp.ActiveURL = active
+	cfg.Marketplaces[name] = mp
+	return Save(cfg)
+}
+
+// marketplaceForRemote finds the git marketplace a remote URL belongs to
+func (c *Config) marketplaceForRemote(url string) (string, Marketplace, bool) {
+	key := normalizeRemote(url)
+	for _, name := range c.sortedMarketplaceNames() {
+		mp := c.Marketplaces[name]
+		if mp.Source.Source != "git" {
+			continue
+		}
+		candidates := append([]string{mp.Source.URL, mp.ActiveURL}, mp.Mirrors...)
+		for _, rule := range mp.Rewrites {
+			candidates = append(candidates, rule.Apply(mp.Source.URL))
+		}
+		for _, candidate := range candidates {
+			if candidate != "" && normalizeRemote(candidate) == key {
+				return name, mp, true
+			}
+		}
+	}
+	return "", Marketplace{}, false
+}
+
+// sortedMarketplaceNames returns marketplace names in a stable order
+func (c *Config) sortedMarketplaceNames() []string {
+	names := make([]string, 0, len(c.Marketplaces))
+	for name := range c.Marketplaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// normalizeRemote strips the differences that don't change which repository a URL names
+func normalizeRemote(url string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git"))
+}
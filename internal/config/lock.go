@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultLockTimeout is how long a command waits for another codex-market
+// process to release the state lock
+const DefaultLockTimeout = 30 * time.Second
+
+// lockPollInterval is how often a held lock is retried
+const lockPollInterval = 100 * time.Millisecond
+
+// LockedError is returned when the state lock is still held by another
+// process after the timeout
+type LockedError struct {
+	PID int // holder's process ID, 0 if unknown
+}
+
+func (e *LockedError) Error() string {
+	if e.PID > 0 {
+		return fmt.Sprintf("another codex-market operation is in progress (pid %d); try again when it finishes", e.PID)
+	}
+	return "another codex-market operation is in progress; try again when it finishes"
+}
+
+// StateLock is an advisory lock on codex-market's state (config.json,
+// installed.json, Codex and Claude config files) held across processes
+type StateLock struct {
+	file *os.File
+}
+
+// AcquireLock takes the state lock, waiting up to timeout for another process
+// to release it. onWait, if non-nil, is called once when the lock is busy.
+// The lock is released by Release, or by the OS when the process exits.
+func AcquireLock(timeout time.Duration, onWait func(pid int)) (*StateLock, error) {
+	if err := EnsureDir(CodexMarketDir()); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(LockPath(), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	waited := false
+	for {
+		ok, err := tryLockFile(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", LockPath(), err)
+		}
+		if ok {
+			break
+		}
+		if !waited && onWait != nil {
+			onWait(lockHolder(f))
+		}
+		waited = true
+		if time.Now().After(deadline) {
+			pid := lockHolder(f)
+			f.Close()
+			return nil, &LockedError{PID: pid}
+		}
+		time.Sleep(lockPollInterval)
+	}
+
+	// Record the holder for the message other processes show
+	f.Truncate(0)
+	f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+
+	// State read before the lock was taken may be stale
+	if err := Reload(); err != nil && !os.IsNotExist(err) {
+		unlockFile(f)
+		f.Close()
+		return nil, err
+	}
+	return &StateLock{file: f}, nil
+}
+
+// Release releases the lock. It is safe to call more than once.
+func (l *StateLock) Release() {
+	if l == nil || l.file == nil {
+		return
+	}
+	l.file.Truncate(0)
+	unlockFile(l.file)
+	l.file.Close()
+	l.file = nil
+}
+
+// lockHolder returns the process ID recorded in the lock file, or 0
+func lockHolder(f *os.File) int {
+	buf := make([]byte, 32)
+	n, _ := f.ReadAt(buf, 0)
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(buf[:n])))
+	return pid
+}
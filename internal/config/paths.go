@@ -35,6 +35,25 @@ func InstalledPath() string {
 	return filepath.Join(CodexMarketDir(), "installed.json")
 }
 
+// LockPath returns the lock file serializing codex-market processes
+// ~/.config/codex-market/lock
+func LockPath() string {
+	return filepath.Join(CodexMarketDir(), "lock")
+}
+
+// BackupDir returns the directory of backups of files codex-market modifies
+// ~/.config/codex-market/backups/
+func BackupDir() string {
+	return filepath.Join(CodexMarketDir(), "backups")
+}
+
+// OriginalsDir returns the directory of installed file contents, kept by hash
+// so local edits can be merged with plugin updates
+// ~/.config/codex-market/originals/
+func OriginalsDir() string {
+	return filepath.Join(CodexMarketDir(), "originals")
+}
+
 // MarketplacesDir returns the marketplaces directory path
 // ~/.config/codex-market/marketplaces/
 func MarketplacesDir() string {
@@ -47,6 +66,12 @@ func PluginCacheDir() string {
 	return filepath.Join(CodexMarketDir(), "cache")
 }
 
+// RepoCacheDir returns the directory of bare clones of remote plugin repositories
+// ~/.config/codex-market/repos/
+func RepoCacheDir() string {
+	return filepath.Join(CodexMarketDir(), "repos")
+}
+
 // ClaudeDir returns the .claude directory path (for Claude settings)
 func ClaudeDir() string {
 	return filepath.Join(homeDir, ".claude")
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Migration upgrades a JSON document by one schema version, in place
+type Migration func(doc map[string]any) error
+
+// NewerSchemaError is returned when writing a file that was produced by a
+// newer codex-market, which would lose whatever the newer schema added
+type NewerSchemaError struct {
+	Path      string
+	Version   int // schema version of the file
+	Supported int // newest schema version this codex-market understands
+}
+
+func (e *NewerSchemaError) Error() string {
+	return fmt.Sprintf("%s was written by a newer codex-market (schema version %d, this version supports up to %d); upgrade codex-market to modify it", e.Path, e.Version, e.Supported)
+}
+
+// MigrateJSON upgrades the JSON document read from path to the current schema
+// version. migrations[i] upgrades version i to i+1, so the current version is
+// len(migrations). Files without a "version" field are version 0, and a null
+// or non-object document is read as an empty one.
+//
+// The upgrade only happens in memory: loading never writes, so it is safe
+// without the state lock. The upgraded document is stored by the next save,
+// which keeps the original (see PrepareWrite). Documents from a newer version
+// are returned unchanged, to be read as well as possible; PrepareWrite
+// refuses to overwrite them.
+func MigrateJSON(path string, data []byte, migrations []Migration) ([]byte, error) {
+	current := len(migrations)
+
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	doc, ok := raw.(map[string]any)
+	if !ok {
+		doc = map[string]any{}
+	}
+	version, err := schemaVersion(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if ok && version >= current {
+		return data, nil
+	}
+
+	for v := version; v < current; v++ {
+		if err := migrations[v](doc); err != nil {
+			return nil, fmt.Errorf("failed to upgrade %s from schema version %d to %d: %w", path, v, v+1, err)
+		}
+	}
+	doc["version"] = current
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// PrepareWrite is called before the file at path is overwritten with schema
+// version current. It fails with a NewerSchemaError if the file was written
+// by a codex-market with a newer schema, and copies a file of an older schema
+// to "<path>.v<N>.bak" (once per version), so downgrading stays possible.
+func PrepareWrite(path string, current int) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var doc map[string]any
+	if json.Unmarshal(data, &doc) != nil {
+		return nil // unreadable files are replaced, as before
+	}
+	version, err := schemaVersion(doc)
+	if err != nil {
+		return nil
+	}
+	if version > current {
+		return &NewerSchemaError{Path: path, Version: version, Supported: current}
+	}
+	if version < current {
+		backupPath := fmt.Sprintf("%s.v%d.bak", path, version)
+		if _, err := os.Stat(backupPath); os.IsNotExist(err) {
+			if err := WriteFileAtomic(backupPath, data, 0644); err != nil {
+				return fmt.Errorf("failed to back up %s before upgrading it: %w", path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// schemaVersion returns the "version" field of a document, 0 if it has none
+func schemaVersion(doc map[string]any) (int, error) {
+	raw, ok := doc["version"]
+	if !ok || raw == nil {
+		return 0, nil
+	}
+	v, ok := raw.(float64)
+	if !ok || v != float64(int(v)) || v < 0 {
+		return 0, fmt.Errorf("invalid schema version %v", raw)
+	}
+	return int(v), nil
+}
+
+// objectField returns doc[key] as an object, creating it if missing
+func objectField(doc map[string]any, key string) map[string]any {
+	obj, ok := doc[key].(map[string]any)
+	if !ok {
+		obj = map[string]any{}
+		doc[key] = obj
+	}
+	return obj
+}
+
+// setDefault sets obj[key] to value if it is missing or an empty string
+func setDefault(obj map[string]any, key string, value any) {
+	if v, ok := obj[key]; !ok || v == nil || v == "" {
+		obj[key] = value
+	}
+}
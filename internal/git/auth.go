@@ -0,0 +1,150 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/egoavara/codex-market/internal/config"
+)
+
+// Environment variables used when codex-market runs itself as GIT_ASKPASS.
+// The token only ever travels through the environment, never through a file.
+const (
+	askpassEnv         = "CODEX_MARKET_ASKPASS"
+	askpassTokenEnv    = "CODEX_MARKET_GIT_TOKEN"
+	askpassUsernameEnv = "CODEX_MARKET_GIT_USERNAME"
+)
+
+// defaultTokenUsername is accepted with a token by GitHub, GitLab and Gitea
+const defaultTokenUsername = "x-access-token"
+
+// RunAskpass answers git's credential prompt when the process was started as
+// GIT_ASKPASS by a git command with token credentials. Returns false otherwise,
+// in which case the caller should run normally.
+func RunAskpass(args []string) bool {
+	if os.Getenv(askpassEnv) != "1" {
+		return false
+	}
+
+	prompt := ""
+	if len(args) > 0 {
+		prompt = args[0]
+	}
+	if strings.HasPrefix(prompt, "Username") {
+		fmt.Println(os.Getenv(askpassUsernameEnv))
+	} else {
+		fmt.Println(os.Getenv(askpassTokenEnv))
+	}
+	return true
+}
+
+// command creates a git command for an operation against a remote. remote is
+// the remote URL, or a repository path whose origin is used. Credentials
+// configured for the remote are passed through the environment.
+// Fails with config.ErrOffline in offline mode.
+func (c *DefaultClient) command(remote string, args ...string) (*exec.Cmd, error) {
+	if remote != "" && config.IsOffline() {
+		return nil, config.ErrOffline
+	}
+	cmd := exec.Command("git", args...)
+
+	auth := c.credentials(remote)
+	if auth == nil {
+		return cmd, nil
+	}
+	env, err := authEnv(auth)
+	if err != nil {
+		return nil, err
+	}
+	cmd.Env = append(os.Environ(), env...)
+	return cmd, nil
+}
+
+// credentials returns the explicitly set credentials, or those configured for the remote
+func (c *DefaultClient) credentials(remote string) *config.MarketplaceAuth {
+	if c.Auth != nil {
+		return c.Auth
+	}
+
+	cfg := config.Get()
+	if remote == "" || !cfg.HasAuth() {
+		return nil
+	}
+	if info, err := os.Stat(remote); err == nil && info.IsDir() {
+		out, err := c.run(remote, "remote", "get-url", "origin")
+		if err != nil {
+			return nil
+		}
+		remote = strings.TrimSpace(out)
+	}
+	return cfg.AuthFor(remote)
+}
+
+// authEnv builds the environment that makes git use the given credentials
+func authEnv(auth *config.MarketplaceAuth) ([]string, error) {
+	// Fail instead of waiting for input nobody will type
+	env := []string{"GIT_TERMINAL_PROMPT=0"}
+	var gitConfig [][2]string
+
+	if auth.TokenEnv != "" || auth.CredentialHelper != "" {
+		// An empty helper resets the list, so global helpers can't shadow these credentials
+		gitConfig = append(gitConfig, [2]string{"credential.helper", ""})
+	}
+	if auth.CredentialHelper != "" {
+		gitConfig = append(gitConfig, [2]string{"credential.helper", auth.CredentialHelper})
+	}
+
+	if auth.TokenEnv != "" {
+		token := os.Getenv(auth.TokenEnv)
+		if token == "" {
+			return nil, fmt.Errorf("environment variable %s is not set (configured as the access token)", auth.TokenEnv)
+		}
+		exe, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate codex-market for GIT_ASKPASS: %w", err)
+		}
+		username := auth.Username
+		if username == "" {
+			username = defaultTokenUsername
+		}
+		env = append(env,
+			"GIT_ASKPASS="+exe,
+			askpassEnv+"=1",
+			askpassTokenEnv+"="+token,
+			askpassUsernameEnv+"="+username,
+		)
+	}
+
+	if auth.SSHKey != "" {
+		key := auth.SSHKey
+		if rest, ok := strings.CutPrefix(key, "~/"); ok {
+			if home, err := os.UserHomeDir(); err == nil {
+				key = filepath.Join(home, rest)
+			}
+		}
+		if _, err := os.Stat(key); err != nil {
+			return nil, fmt.Errorf("SSH key not found: %s", key)
+		}
+		env = append(env, "GIT_SSH_COMMAND=ssh -i "+shellQuote(filepath.ToSlash(key))+" -o IdentitiesOnly=yes")
+	}
+
+	if len(gitConfig) > 0 {
+		env = append(env, "GIT_CONFIG_COUNT="+strconv.Itoa(len(gitConfig)))
+		for i, kv := range gitConfig {
+			env = append(env,
+				fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", i, kv[0]),
+				fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", i, kv[1]),
+			)
+		}
+	}
+	return env, nil
+}
+
+// shellQuote quotes s for the POSIX shell git runs GIT_SSH_COMMAND with
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,102 @@
+package git
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// unsafeCacheChars matches characters replaced when deriving cache folder names
+var unsafeCacheChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// CacheKey returns the folder name of the bare clone cache for a repository URL.
+// The readable part is derived from the URL; the hash keeps distinct URLs apart.
+func CacheKey(url string) string {
+	name := strings.TrimSuffix(url, ".git")
+	if i := strings.Index(name, "://"); i >= 0 {
+		name = name[i+3:]
+	}
+	name = strings.Trim(unsafeCacheChars.ReplaceAllString(name, "-"), "-.")
+	if len(name) > 64 {
+		name = name[len(name)-64:]
+	}
+
+	sum := sha256.Sum256([]byte(url))
+	return name + "-" + hex.EncodeToString(sum[:4]) + ".git"
+}
+
+// UpdateCache creates a bare clone of url at cachePath, or fetches new
+// branches and tags into an existing one
+func (c *DefaultClient) UpdateCache(url, cachePath string) error {
+	if _, err := os.Stat(filepath.Join(cachePath, "HEAD")); err == nil {
+		err := c.tryOrigins(cachePath, func() error {
+			_, err := c.runRemote(url, cachePath, "fetch", "--quiet", "--prune", "--tags", "origin")
+			return err
+		})
+		if err != nil {
+			return wrapRemoteError(url, "git fetch failed", err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		return err
+	}
+	err := c.tryURLs(url, func(url string) error {
+		_, err := c.runRemote(url, "", "clone", "--quiet", "--bare", url, cachePath)
+		return err
+	})
+	if err != nil {
+		os.RemoveAll(cachePath)
+		return wrapRemoteError(url, "git clone failed", err)
+	}
+
+	// Bare clones don't configure a fetch refspec; mirror branches so later fetches update them
+	if _, err := c.run(cachePath, "config", "remote.origin.fetch", "+refs/heads/*:refs/heads/*"); err != nil {
+		os.RemoveAll(cachePath)
+		return fmt.Errorf("git config failed: %w", err)
+	}
+	return nil
+}
+
+// CheckoutCache checks out rev (a commit, branch or tag; "" for the default branch)
+// from a bare clone cache into destPath and returns the resolved commit.
+// destPath receives only the working tree, without a .git folder.
+func (c *DefaultClient) CheckoutCache(cachePath, rev, destPath string) (string, error) {
+	commit, err := c.resolveCachedRev(cachePath, rev)
+	if err != nil {
+		return "", err
+	}
+
+	// --shared borrows objects from the cache instead of copying them
+	if _, err := c.run("", "clone", "--quiet", "--shared", "--no-checkout", cachePath, destPath); err != nil {
+		return "", fmt.Errorf("git clone failed: %w", err)
+	}
+	if _, err := c.run(destPath, "checkout", "--quiet", "--detach", commit); err != nil {
+		return "", fmt.Errorf("git checkout failed: %w", err)
+	}
+	if err := os.RemoveAll(filepath.Join(destPath, ".git")); err != nil {
+		return "", err
+	}
+	return commit, nil
+}
+
+// resolveCachedRev resolves a commit, branch or tag to a full commit SHA in a bare clone
+func (c *DefaultClient) resolveCachedRev(cachePath, rev string) (string, error) {
+	candidates := []string{"HEAD"}
+	if rev != "" {
+		candidates = []string{"refs/heads/" + rev, "refs/tags/" + rev, rev}
+	}
+
+	for _, candidate := range candidates {
+		out, err := c.run(cachePath, "rev-parse", "--verify", "--quiet", candidate+"^{commit}")
+		if err == nil {
+			return strings.TrimSpace(out), nil
+		}
+	}
+	return "", fmt.Errorf("revision %q not found in %s", rev, cachePath)
+}
@@ -6,6 +6,8 @@ import (
 	"os/exec"
 	"strings"
 	"time"
+
+	"github.com/egoavara/codex-market/internal/config"
 )
 
 // Client is the interface for git operations
@@ -17,11 +19,26 @@ type Client interface {
 	GetRemoteCommit(repoPath, branch string) (string, error)
 	HasUpdates(repoPath string) (bool, error)
 	IsGitRepository(path string) bool
+	ResolveRef(url, ref string) (RefKind, error)
+	CloneRef(url, destPath, ref string, kind RefKind) error
+	CheckoutRef(repoPath, ref string) error
+	CheckoutBranch(repoPath, branch string) error
+	GetDefaultBranch(repoPath string) (string, error)
+	ListRemoteTags(repoPath string) ([]string, error)
+	GetRemoteRefCommit(url, ref string) (string, error)
+	UpdateCache(url, cachePath string) error
+	CheckoutCache(cachePath, rev, destPath string) (string, error)
 }
 
 // DefaultClient is the default git client implementation
 type DefaultClient struct {
 	Timeout time.Duration
+	Auth    *config.MarketplaceAuth // credentials to use; nil looks them up per remote in the config
+
+	// Fallback URLs for a marketplace that isn't registered yet;
+	// if both are empty, mirrors are looked up per remote in the config
+	Mirrors  []string
+	Rewrites []config.HostRewrite
 }
 
 // NewClient creates a new git client
@@ -31,14 +48,23 @@ func NewClient() *DefaultClient {
 	}
 }
 
-// Clone clones a git repository to the specified path
+// Clone clones a git repository to the specified path, falling back to its mirrors
 func (c *DefaultClient) Clone(url, destPath string) error {
-	cmd := exec.Command("git", "clone", "--depth", "1", url, destPath)
+	return c.tryURLs(url, func(url string) error {
+		return c.clone(url, destPath)
+	})
+}
+
+func (c *DefaultClient) clone(url, destPath string) error {
+	cmd, err := c.command(url, "clone", "--depth", "1", url, destPath)
+	if err != nil {
+		return err
+	}
 
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
-	err := cmd.Run()
+	err = cmd.Run()
 	if err != nil {
 		errMsg := stderr.String()
 		if isAuthError(errMsg) {
@@ -50,14 +76,23 @@ func (c *DefaultClient) Clone(url, destPath string) error {
 	return nil
 }
 
-// Pull pulls the latest changes in a git repository
+// Pull pulls the latest changes in a git repository, falling back to its mirrors
 func (c *DefaultClient) Pull(repoPath string) error {
-	cmd := exec.Command("git", "-C", repoPath, "pull", "--ff-only")
+	return c.tryOrigins(repoPath, func() error {
+		return c.pull(repoPath)
+	})
+}
+
+func (c *DefaultClient) pull(repoPath string) error {
+	cmd, err := c.command(repoPath, "-C", repoPath, "pull", "--ff-only")
+	if err != nil {
+		return err
+	}
 
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
-	err := cmd.Run()
+	err = cmd.Run()
 	if err != nil {
 		errMsg := stderr.String()
 		if isAuthError(errMsg) {
@@ -91,14 +126,23 @@ func (c *DefaultClient) IsGitRepository(path string) bool {
 	return err == nil
 }
 
-// Fetch fetches changes from remote without merging
+// Fetch fetches changes from remote without merging, falling back to its mirrors
 func (c *DefaultClient) Fetch(repoPath string) error {
-	cmd := exec.Command("git", "-C", repoPath, "fetch", "--quiet")
+	return c.tryOrigins(repoPath, func() error {
+		return c.fetch(repoPath)
+	})
+}
+
+func (c *DefaultClient) fetch(repoPath string) error {
+	cmd, err := c.command(repoPath, "-C", repoPath, "fetch", "--quiet")
+	if err != nil {
+		return err
+	}
 
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
-	err := cmd.Run()
+	err = cmd.Run()
 	if err != nil {
 		errMsg := stderr.String()
 		if isAuthError(errMsg) {
@@ -0,0 +1,52 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// MergeFile merges the changes between base and updated into current, like
+// "git merge-file". Conflicting hunks are left in the result with conflict
+// markers, and conflicts reports whether there were any.
+func MergeFile(current, base, updated []byte) (merged []byte, conflicts bool, err error) {
+	dir, err := os.MkdirTemp("", "codex-market-merge-*")
+	if err != nil {
+		return nil, false, err
+	}
+	defer os.RemoveAll(dir)
+
+	paths := make([]string, 3)
+	for i, data := range [][]byte{current, base, updated} {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("%d", i))
+		if err := os.WriteFile(paths[i], data, 0600); err != nil {
+			return nil, false, err
+		}
+	}
+
+	cmd := exec.Command("git", "merge-file", "-p",
+		"-L", "local", "-L", "installed", "-L", "updated",
+		paths[0], paths[1], paths[2])
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+
+	// The exit code is the number of conflicts, or negative on error
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 && exitErr.ExitCode() < 128 {
+		return stdout.Bytes(), true, nil
+	}
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, false, fmt.Errorf("git merge-file failed: %s", msg)
+	}
+	return stdout.Bytes(), false, nil
+}
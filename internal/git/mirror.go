@@ -0,0 +1,96 @@
+package git
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/egoavara/codex-market/internal/config"
+)
+
+// remoteURLs returns the URLs to try for a remote, in order. Without mirrors
+// or host rewrites this is just url.
+func (c *DefaultClient) remoteURLs(url string) []string {
+	if len(c.Mirrors) > 0 || len(c.Rewrites) > 0 {
+		urls := append([]string{url}, c.Mirrors...)
+		for _, rule := range c.Rewrites {
+			if rewritten := rule.Apply(url); rewritten != "" {
+				urls = append(urls, rewritten)
+			}
+		}
+		return urls
+	}
+
+	cfg := config.Get()
+	if !cfg.HasMirrors() {
+		return []string{url}
+	}
+	return cfg.RemoteURLs(url)
+}
+
+// tryURLs runs op with each URL of a remote until one succeeds, and records
+// the URL that worked for its marketplace. Returns the first error if all fail.
+func (c *DefaultClient) tryURLs(url string, op func(url string) error) error {
+	urls := c.remoteURLs(url)
+
+	var firstErr error
+	for _, candidate := range urls {
+		err := op(candidate)
+		if errors.Is(err, config.ErrOffline) {
+			return err
+		}
+		if err == nil {
+			if len(urls) > 1 {
+				config.RecordActiveURL(url, candidate)
+			}
+			return nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// tryOrigins runs op in a repository. If it fails and the repository's origin
+// has mirrors, origin is pointed at each of them in turn until op succeeds;
+// the mirror that worked is kept and recorded for its marketplace.
+func (c *DefaultClient) tryOrigins(repoPath string, op func() error) error {
+	err := op()
+	if err == nil || errors.Is(err, config.ErrOffline) {
+		return err
+	}
+
+	origin, urlErr := c.RemoteURL(repoPath)
+	if urlErr != nil {
+		return err
+	}
+	urls := c.remoteURLs(origin)
+	if len(urls) < 2 {
+		return err
+	}
+
+	for _, candidate := range urls {
+		if candidate == origin {
+			continue
+		}
+		if _, setErr := c.run(repoPath, "remote", "set-url", "origin", candidate); setErr != nil {
+			break
+		}
+		if op() == nil {
+			config.RecordActiveURL(origin, candidate)
+			return nil
+		}
+	}
+
+	c.run(repoPath, "remote", "set-url", "origin", origin)
+	return err
+}
+
+// RemoteURL returns the URL of a repository's origin
+func (c *DefaultClient) RemoteURL(repoPath string) (string, error) {
+	out, err := c.run(repoPath, "remote", "get-url", "origin")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
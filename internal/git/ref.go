@@ -0,0 +1,328 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/egoavara/codex-market/internal/config"
+)
+
+// RefKind describes what a pinned ref points at
+type RefKind string
+
+const (
+	RefBranch RefKind = "branch"
+	RefTag    RefKind = "tag"
+	RefCommit RefKind = "commit"
+)
+
+// commitPattern matches full or abbreviated commit SHAs
+var commitPattern = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+
+// SplitRef splits "url#ref" into the URL and ref. Returns an empty ref if there is none.
+func SplitRef(url string) (string, string) {
+	if i := strings.LastIndex(url, "#"); i >= 0 {
+		return url[:i], url[i+1:]
+	}
+	return url, ""
+}
+
+// ResolveRef determines whether ref names a branch, tag or commit of the remote repository
+func (c *DefaultClient) ResolveRef(url, ref string) (RefKind, error) {
+	var out string
+	err := c.tryURLs(url, func(url string) (err error) {
+		out, err = c.runRemote(url, "", "ls-remote", url, "refs/heads/"+ref, "refs/tags/"+ref)
+		return err
+	})
+	if err != nil {
+		return "", wrapRemoteError(url, "git ls-remote failed", err)
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[1] {
+		case "refs/heads/" + ref:
+			return RefBranch, nil
+		case "refs/tags/" + ref:
+			return RefTag, nil
+		}
+	}
+
+	if commitPattern.MatchString(ref) {
+		return RefCommit, nil
+	}
+	return "", fmt.Errorf("ref %q not found in %s", ref, url)
+}
+
+// GetRemoteRefCommit returns the commit a remote branch or tag points at,
+// or the commit of the remote HEAD if ref is empty
+func (c *DefaultClient) GetRemoteRefCommit(url, ref string) (string, error) {
+	patterns := []string{"HEAD"}
+	if ref != "" {
+		// Annotated tags are listed twice; the peeled "^{}" entry is the commit
+		patterns = []string{"refs/heads/" + ref, "refs/tags/" + ref, "refs/tags/" + ref + "^{}"}
+	}
+
+	var out string
+	err := c.tryURLs(url, func(url string) (err error) {
+		out, err = c.runRemote(url, "", append([]string{"ls-remote", url}, patterns...)...)
+		return err
+	})
+	if err != nil {
+		return "", wrapRemoteError(url, "git ls-remote failed", err)
+	}
+
+	commit := ""
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if strings.HasSuffix(fields[1], "^{}") || commit == "" {
+			commit = fields[0]
+		}
+	}
+	if commit == "" {
+		return "", fmt.Errorf("ref %q not found in %s", ref, url)
+	}
+	return commit, nil
+}
+
+// SameCommit reports whether two commit SHAs refer to the same commit,
+// allowing either to be abbreviated (at least 7 characters)
+func SameCommit(a, b string) bool {
+	n := min(len(a), len(b))
+	if n < 7 {
+		return a == b
+	}
+	return strings.EqualFold(a[:n], b[:n])
+}
+
+// CloneRef clones a repository checked out at the given ref.
+// Branches and tags are cloned shallowly; commits are fetched directly when the
+// server allows it, falling back to a full clone.
+func (c *DefaultClient) CloneRef(url, destPath, ref string, kind RefKind) error {
+	if kind != RefCommit {
+		err := c.tryURLs(url, func(url string) error {
+			_, err := c.runRemote(url, "", "clone", "--depth", "1", "--branch", ref, url, destPath)
+			return err
+		})
+		if err != nil {
+			return wrapRemoteError(url, "git clone failed", err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(destPath, 0755); err != nil {
+		return err
+	}
+	if _, err := c.run(destPath, "init", "--quiet"); err != nil {
+		return fmt.Errorf("git init failed: %w", err)
+	}
+	if _, err := c.run(destPath, "remote", "add", "origin", url); err != nil {
+		return fmt.Errorf("git remote add failed: %w", err)
+	}
+	if err := c.CheckoutRef(destPath, ref); err == nil {
+		return nil
+	}
+
+	// Abbreviated or unadvertised commits need the full history
+	os.RemoveAll(destPath)
+	err := c.tryURLs(url, func(url string) error {
+		_, err := c.runRemote(url, "", "clone", url, destPath)
+		return err
+	})
+	if err != nil {
+		return wrapRemoteError(url, "git clone failed", err)
+	}
+	if _, err := c.run(destPath, "checkout", "--quiet", "--detach", ref); err != nil {
+		return fmt.Errorf("git checkout failed: %w", err)
+	}
+	return nil
+}
+
+// CheckoutRef fetches a tag or commit and checks it out as a detached HEAD
+func (c *DefaultClient) CheckoutRef(repoPath, ref string) error {
+	err := c.tryOrigins(repoPath, func() error {
+		_, err := c.runRemote(repoPath, repoPath, "fetch", "--quiet", "--depth", "1", "origin", ref)
+		return err
+	})
+	if err != nil {
+		return wrapRemoteError(repoPath, "git fetch failed", err)
+	}
+	if _, err := c.run(repoPath, "checkout", "--quiet", "--force", "--detach", "FETCH_HEAD"); err != nil {
+		return fmt.Errorf("git checkout failed: %w", err)
+	}
+	return nil
+}
+
+// CheckoutBranch switches the repository to track a remote branch, so that
+// Pull and HasUpdates follow that branch
+func (c *DefaultClient) CheckoutBranch(repoPath, branch string) error {
+	// Shallow clones only fetch their initial branch; add this one to the refspec
+	if _, err := c.run(repoPath, "remote", "set-branches", "--add", "origin", branch); err != nil {
+		return fmt.Errorf("git remote set-branches failed: %w", err)
+	}
+	refspec := fmt.Sprintf("+refs/heads/%s:refs/remotes/origin/%s", branch, branch)
+	err := c.tryOrigins(repoPath, func() error {
+		_, err := c.runRemote(repoPath, repoPath, "fetch", "--quiet", "--depth", "1", "origin", refspec)
+		return err
+	})
+	if err != nil {
+		return wrapRemoteError(repoPath, "git fetch failed", err)
+	}
+	if _, err := c.run(repoPath, "checkout", "--quiet", "--force", "-B", branch, "origin/"+branch); err != nil {
+		return fmt.Errorf("git checkout failed: %w", err)
+	}
+	if _, err := c.run(repoPath, "branch", "--quiet", "--set-upstream-to=origin/"+branch); err != nil {
+		return fmt.Errorf("git branch failed: %w", err)
+	}
+	return nil
+}
+
+// GetDefaultBranch returns the branch the remote's HEAD points at
+func (c *DefaultClient) GetDefaultBranch(repoPath string) (string, error) {
+	var out string
+	err := c.tryOrigins(repoPath, func() (err error) {
+		out, err = c.runRemote(repoPath, repoPath, "ls-remote", "--symref", "origin", "HEAD")
+		return err
+	})
+	if err != nil {
+		return "", wrapRemoteError(repoPath, "git ls-remote failed", err)
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		if rest, ok := strings.CutPrefix(line, "ref: refs/heads/"); ok {
+			if branch, _, ok := strings.Cut(rest, "\t"); ok {
+				return branch, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("failed to determine default branch of %s", repoPath)
+}
+
+// ListRemoteTags returns the tag names of the repository's origin
+func (c *DefaultClient) ListRemoteTags(repoPath string) ([]string, error) {
+	var out string
+	err := c.tryOrigins(repoPath, func() (err error) {
+		out, err = c.runRemote(repoPath, repoPath, "ls-remote", "--tags", "--refs", "origin")
+		return err
+	})
+	if err != nil {
+		return nil, wrapRemoteError(repoPath, "git ls-remote failed", err)
+	}
+
+	var tags []string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			tags = append(tags, strings.TrimPrefix(fields[1], "refs/tags/"))
+		}
+	}
+	return tags, nil
+}
+
+// NewerTags returns the version-like tags that sort after current, newest first.
+// Tags that don't look like versions (e.g., "latest") are ignored.
+func NewerTags(tags []string, current string) []string {
+	cur, ok := parseVersion(current)
+	if !ok {
+		return nil
+	}
+
+	var newer []string
+	for _, tag := range tags {
+		if v, ok := parseVersion(tag); ok && compareVersions(v, cur) > 0 {
+			newer = append(newer, tag)
+		}
+	}
+
+	sort.Slice(newer, func(i, j int) bool {
+		a, _ := parseVersion(newer[i])
+		b, _ := parseVersion(newer[j])
+		return compareVersions(a, b) > 0
+	})
+	return newer
+}
+
+// parseVersion parses "v1.2.3" style tags into numeric components.
+// Pre-release tags ("v1.2.3-rc1") are not treated as versions.
+func parseVersion(tag string) ([]int, bool) {
+	parts := strings.Split(strings.TrimPrefix(tag, "v"), ".")
+	nums := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, false
+		}
+		nums[i] = n
+	}
+	return nums, true
+}
+
+// compareVersions compares numeric version components, treating missing components as 0
+func compareVersions(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x > y {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
+
+// run executes a git command (in dir if non-empty) and returns its stdout.
+// On failure the error carries git's stderr.
+func (c *DefaultClient) run(dir string, args ...string) (string, error) {
+	return c.runRemote("", dir, args...)
+}
+
+// runRemote is run for commands that contact remote, a URL or repository path,
+// using the credentials configured for it
+func (c *DefaultClient) runRemote(remote, dir string, args ...string) (string, error) {
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+	cmd, err := c.command(remote, args...)
+	if err != nil {
+		return "", err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s", strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// wrapRemoteError converts a failed remote operation into an AuthError when appropriate
+func wrapRemoteError(url, action string, err error) error {
+	if errors.Is(err, config.ErrOffline) {
+		return err
+	}
+	if isAuthError(err.Error()) {
+		return &AuthError{URL: url, Message: err.Error()}
+	}
+	return fmt.Errorf("%s: %s", action, err)
+}
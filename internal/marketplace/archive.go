@@ -0,0 +1,362 @@
+package marketplace
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/egoavara/codex-market/internal/config"
+)
+
+// maxArchiveSize limits both the downloaded archive and its extracted contents
+const maxArchiveSize = 512 << 20
+
+// sha256Pattern matches a hex-encoded SHA-256 checksum
+var sha256Pattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// IsRemoteArchive reports whether an archive location is an HTTP(S) URL
+// rather than a local path
+func IsRemoteArchive(location string) bool {
+	return strings.HasPrefix(location, "https://") || strings.HasPrefix(location, "http://")
+}
+
+// ArchiveLocation resolves where an archive plugin source is fetched from.
+// Relative paths are resolved against the manifest URL for url marketplaces,
+// and against pluginRoot (like path sources) otherwise.
+func (m *MarketplaceManifest) ArchiveLocation(mp *KnownMarketplace, plugin *PluginEntry) (string, error) {
+	location := plugin.Source.URL
+	if IsRemoteArchive(location) || filepath.IsAbs(location) {
+		return location, nil
+	}
+
+	if mp.Source.Source == SourceURL {
+		base, err := url.Parse(mp.Source.URL)
+		if err != nil {
+			return "", fmt.Errorf("invalid marketplace URL %s: %w", mp.Source.URL, err)
+		}
+		ref, err := url.Parse(filepath.ToSlash(location))
+		if err != nil {
+			return "", fmt.Errorf("invalid archive location %q: %w", location, err)
+		}
+		return base.ResolveReference(ref).String(), nil
+	}
+
+	basePath := mp.InstallLocation
+	if m.Metadata != nil && m.Metadata.PluginRoot != "" {
+		basePath = filepath.Join(basePath, m.Metadata.PluginRoot)
+	}
+	return filepath.Join(basePath, location), nil
+}
+
+// DownloadArchive downloads an archive to destPath and returns its SHA-256
+func (f *Fetcher) DownloadArchive(url, destPath string) (string, error) {
+	if config.IsOffline() {
+		return "", config.ErrOffline
+	}
+	resp, err := f.Client.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+
+	out, err := os.Create(destPath)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(out, h), io.LimitReader(resp.Body, maxArchiveSize+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	if n > maxArchiveSize {
+		return "", fmt.Errorf("failed to download %s: archive exceeds %d bytes", url, maxArchiveSize)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HashFile returns the SHA-256 of a file
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// VerifyChecksum compares an archive's SHA-256 against the expected value
+func VerifyChecksum(location, got, want string) error {
+	if !strings.EqualFold(got, want) {
+		return fmt.Errorf("checksum mismatch for %s: expected sha256 %s, got %s", location, strings.ToLower(want), got)
+	}
+	return nil
+}
+
+// ExtractArchive extracts a .tar.gz, .tar or .zip archive into destDir.
+// The format is detected from the file contents. Entries with absolute paths,
+// ".." components or links pointing outside destDir are rejected.
+func ExtractArchive(archivePath, destDir string) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	magic, _ := bufio.NewReader(f).Peek(4)
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")), bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		return extractZip(f, info.Size(), destDir)
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("invalid gzip archive: %w", err)
+		}
+		defer gz.Close()
+		return extractTar(gz, destDir)
+	default:
+		return extractTar(f, destDir)
+	}
+}
+
+// ArchiveRoot returns the directory holding an extracted archive's contents.
+// Release archives usually wrap everything in a single top-level folder
+// (e.g., "my-plugin-1.0.0/"), which is descended into.
+func ArchiveRoot(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() {
+		return dir
+	}
+	return filepath.Join(dir, entries[0].Name())
+}
+
+func extractTar(r io.Reader, destDir string) error {
+	tr := tar.NewReader(r)
+	var total int64
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid tar archive: %w", err)
+		}
+
+		target, err := archiveTarget(destDir, hdr.Name)
+		if err != nil {
+			return err
+		}
+		if target == "" {
+			continue
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if _, err := archiveDir(destDir, target, hdr.Name); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if total += hdr.Size; total > maxArchiveSize {
+				return fmt.Errorf("archive contents exceed %d bytes", maxArchiveSize)
+			}
+			if err := writeArchiveFile(destDir, target, hdr.Name, tr, hdr.FileInfo().Mode()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := writeArchiveSymlink(destDir, target, hdr.Name, hdr.Linkname); err != nil {
+				return err
+			}
+		case tar.TypeXGlobalHeader:
+			// pax metadata, not a file
+		default:
+			return fmt.Errorf("unsupported archive entry %q: only files, directories and symlinks are allowed", hdr.Name)
+		}
+	}
+}
+
+func extractZip(r io.ReaderAt, size int64, destDir string) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("invalid zip archive: %w", err)
+	}
+
+	var total uint64
+	for _, zf := range zr.File {
+		target, err := archiveTarget(destDir, zf.Name)
+		if err != nil {
+			return err
+		}
+		if target == "" {
+			continue
+		}
+
+		mode := zf.Mode()
+		switch {
+		case mode.IsDir():
+			if _, err := archiveDir(destDir, target, zf.Name); err != nil {
+				return err
+			}
+		case mode&os.ModeSymlink != 0:
+			rc, err := zf.Open()
+			if err != nil {
+				return err
+			}
+			link, err := io.ReadAll(io.LimitReader(rc, 4096))
+			rc.Close()
+			if err != nil {
+				return err
+			}
+			if err := writeArchiveSymlink(destDir, target, zf.Name, string(link)); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			if total += zf.UncompressedSize64; total > maxArchiveSize {
+				return fmt.Errorf("archive contents exceed %d bytes", maxArchiveSize)
+			}
+			rc, err := zf.Open()
+			if err != nil {
+				return err
+			}
+			err = writeArchiveFile(destDir, target, zf.Name, rc, mode)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported archive entry %q: only files, directories and symlinks are allowed", zf.Name)
+		}
+	}
+	return nil
+}
+
+// archiveTarget maps an archive entry name to a path inside destDir.
+// Returns "" for entries that resolve to destDir itself (e.g., "./").
+func archiveTarget(destDir, name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if path.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("unsafe archive entry %q: absolute paths are not allowed", name)
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("unsafe archive entry %q: path traversal is not allowed", name)
+		}
+	}
+
+	clean := path.Clean(name)
+	if clean == "." {
+		return "", nil
+	}
+	return filepath.Join(destDir, filepath.FromSlash(clean)), nil
+}
+
+// writeArchiveSymlink creates a symlink after checking that it stays inside destDir
+func writeArchiveSymlink(destDir, target, name, link string) error {
+	parent, err := archiveDir(destDir, filepath.Dir(target), name)
+	if err != nil {
+		return err
+	}
+	link = filepath.FromSlash(link)
+	if filepath.IsAbs(link) || !isWithin(realPath(destDir), filepath.Join(parent, link)) {
+		return fmt.Errorf("unsafe archive entry %q: link target %q is outside the archive", name, link)
+	}
+	os.Remove(target)
+	return os.Symlink(link, target)
+}
+
+func writeArchiveFile(destDir, target, name string, r io.Reader, mode os.FileMode) error {
+	if _, err := archiveDir(destDir, filepath.Dir(target), name); err != nil {
+		return err
+	}
+	// Never write through a symlink extracted earlier
+	if info, err := os.Lstat(target); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		os.Remove(target)
+	}
+
+	// Keep the executable bit (hook scripts, MCP servers) but nothing more exotic
+	perm := os.FileMode(0644)
+	if mode&0111 != 0 {
+		perm = 0755
+	}
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// archiveDir creates dir and returns its real path. Fails, before creating
+// anything, if a symlink extracted earlier redirects it outside destDir.
+func archiveDir(destDir, dir, name string) (string, error) {
+	// Resolve the deepest existing ancestor; the rest can't contain symlinks yet
+	existing, rest := dir, ""
+	for {
+		if _, err := os.Lstat(existing); err == nil || existing == destDir {
+			break
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = filepath.Dir(existing)
+	}
+
+	real := filepath.Join(realPath(existing), rest)
+	if !isWithin(realPath(destDir), real) {
+		return "", fmt.Errorf("unsafe archive entry %q: path leads outside the archive", name)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return real, nil
+}
+
+// realPath resolves symlinks in path, returning path unchanged if that fails
+func realPath(path string) string {
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		return real
+	}
+	return path
+}
+
+// isWithin reports whether path is dir or inside it
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,250 @@
+package marketplace
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// archiveEntry is a file, directory or symlink in a test archive
+type archiveEntry struct {
+	name string
+	body string
+	link string // symlink target
+	dir  bool
+	size int64 // declared size, if it differs from len(body)
+}
+
+// archiveFormats build an archive of the entries in each supported format
+var archiveFormats = map[string]func(t *testing.T, entries []archiveEntry) []byte{
+	"tar.gz": func(t *testing.T, entries []archiveEntry) []byte {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		gz.Write(buildTar(t, entries))
+		gz.Close()
+		return buf.Bytes()
+	},
+	"tar": buildTar,
+	"zip": buildZip,
+}
+
+func buildTar(t *testing.T, entries []archiveEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: 0644, Size: int64(len(e.body)), Typeflag: tar.TypeReg}
+		switch {
+		case e.dir:
+			hdr.Typeflag, hdr.Mode, hdr.Size = tar.TypeDir, 0755, 0
+		case e.link != "":
+			hdr.Typeflag, hdr.Linkname, hdr.Size = tar.TypeSymlink, e.link, 0
+		}
+		if e.size > 0 {
+			// Only the header is written; extraction must stop before reading
+			hdr.Size = e.size
+			if err := tw.WriteHeader(hdr); err != nil {
+				t.Fatal(err)
+			}
+			return buf.Bytes()
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func buildZip(t *testing.T, entries []archiveEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		fh := &zip.FileHeader{Name: e.name, Method: zip.Store}
+		body := e.body
+		switch {
+		case e.dir:
+			fh.Name = strings.TrimSuffix(e.name, "/") + "/"
+			fh.SetMode(os.ModeDir | 0755)
+		case e.link != "":
+			fh.SetMode(os.ModeSymlink | 0777)
+			body = e.link
+		default:
+			fh.SetMode(0644)
+		}
+		var err error
+		if e.size > 0 {
+			// Declare a size the (empty) data doesn't have
+			fh.UncompressedSize64 = uint64(e.size)
+			_, err = zw.CreateRaw(fh)
+		} else {
+			var w io.Writer
+			if w, err = zw.CreateHeader(fh); err == nil {
+				_, err = w.Write([]byte(body))
+			}
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// extract writes an archive to disk and extracts it into a new directory
+func extract(t *testing.T, data []byte) (string, error) {
+	t.Helper()
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "plugin.archive")
+	if err := os.WriteFile(archive, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(dir, "out")
+	return dest, ExtractArchive(archive, dest)
+}
+
+func TestExtractArchive(t *testing.T) {
+	for format, build := range archiveFormats {
+		t.Run(format, func(t *testing.T) {
+			dest, err := extract(t, build(t, []archiveEntry{
+				{name: "plugin/", dir: true},
+				{name: "plugin/SKILL.md", body: "skill"},
+				{name: "plugin/docs/guide.md", body: "guide"},
+				{name: "plugin/readme.md", link: "docs/guide.md"},
+			}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, want := range map[string]string{"SKILL.md": "skill", "docs/guide.md": "guide", "readme.md": "guide"} {
+				got, err := os.ReadFile(filepath.Join(dest, "plugin", filepath.FromSlash(name)))
+				if err != nil || string(got) != want {
+					t.Errorf("%s = %q, %v; want %q", name, got, err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractArchiveRejectsUnsafeEntries(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []archiveEntry
+		wantErr string
+	}{
+		{
+			name:    "parent traversal",
+			entries: []archiveEntry{{name: "plugin/../../evil.txt", body: "x"}},
+			wantErr: "path traversal",
+		},
+		{
+			name:    "absolute path",
+			entries: []archiveEntry{{name: "/tmp/evil.txt", body: "x"}},
+			wantErr: "absolute paths",
+		},
+		{
+			name:    "symlink outside the archive",
+			entries: []archiveEntry{{name: "escape", link: "../../outside"}},
+			wantErr: "outside the archive",
+		},
+		{
+			name:    "absolute symlink",
+			entries: []archiveEntry{{name: "escape", link: "/etc"}},
+			wantErr: "outside the archive",
+		},
+		{
+			name: "symlink through an earlier symlink",
+			entries: []archiveEntry{
+				{name: "here", link: "."},
+				{name: "here/escape", link: ".."},
+			},
+			wantErr: "outside the archive",
+		},
+		{
+			name:    "size limit",
+			entries: []archiveEntry{{name: "huge.bin", size: maxArchiveSize + 1}},
+			wantErr: "exceed",
+		},
+	}
+
+	for format, build := range archiveFormats {
+		for _, tt := range tests {
+			t.Run(format+"/"+tt.name, func(t *testing.T) {
+				dest, err := extract(t, build(t, tt.entries))
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want one containing %q", err, tt.wantErr)
+				}
+				if _, err := os.Stat(filepath.Join(filepath.Dir(dest), "evil.txt")); err == nil {
+					t.Error("entry was written outside the destination")
+				}
+			})
+		}
+	}
+}
+
+func TestExtractArchiveDoesNotWriteThroughSymlinks(t *testing.T) {
+	for format, build := range archiveFormats {
+		t.Run(format, func(t *testing.T) {
+			dest, err := extract(t, build(t, []archiveEntry{
+				{name: "target.txt", body: "original"},
+				{name: "link", link: "target.txt"},
+				{name: "link", body: "replaced"},
+			}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := os.ReadFile(filepath.Join(dest, "target.txt")); string(got) != "original" {
+				t.Errorf("target.txt = %q, want it untouched", got)
+			}
+			info, err := os.Lstat(filepath.Join(dest, "link"))
+			if err != nil || !info.Mode().IsRegular() {
+				t.Errorf("link should have been replaced by a regular file: %v, %v", info, err)
+			}
+		})
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+	if err := VerifyChecksum("plugin.tar.gz", sum, strings.ToUpper(sum)); err != nil {
+		t.Errorf("matching checksum (case-insensitive): %v", err)
+	}
+	err := VerifyChecksum("plugin.tar.gz", sum, strings.Repeat("cd", 32))
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("err = %v, want a checksum mismatch", err)
+	}
+}
+
+func TestArchiveRoot(t *testing.T) {
+	single := t.TempDir()
+	os.MkdirAll(filepath.Join(single, "my-plugin-1.0.0", "skills"), 0755)
+	if got := ArchiveRoot(single); got != filepath.Join(single, "my-plugin-1.0.0") {
+		t.Errorf("ArchiveRoot(single folder) = %s, want the folder", got)
+	}
+
+	flat := t.TempDir()
+	os.MkdirAll(filepath.Join(flat, "skills"), 0755)
+	os.WriteFile(filepath.Join(flat, "README.md"), nil, 0644)
+	if got := ArchiveRoot(flat); got != flat {
+		t.Errorf("ArchiveRoot(flat) = %s, want the directory itself", got)
+	}
+
+	file := t.TempDir()
+	os.WriteFile(filepath.Join(file, "plugin.json"), nil, 0644)
+	if got := ArchiveRoot(file); got != file {
+		t.Errorf("ArchiveRoot(single file) = %s, want the directory itself", got)
+	}
+}
@@ -0,0 +1,146 @@
+package marketplace
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/egoavara/codex-market/internal/config"
+)
+
+// maxManifestSize limits how much of a remote marketplace.json is read
+const maxManifestSize = 10 << 20
+
+// FetchInfo holds the HTTP caching state of a url marketplace
+type FetchInfo struct {
+	ETag         string
+	LastModified string
+	Hash         string // SHA-256 of the stored manifest
+}
+
+// Fetcher downloads marketplace manifests published over HTTP(S)
+type Fetcher struct {
+	Client *http.Client
+}
+
+// NewFetcher creates a new manifest fetcher
+func NewFetcher() *Fetcher {
+	return &Fetcher{
+		Client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// IsManifestURL reports whether a marketplace argument points at a
+// marketplace.json served over HTTP(S) rather than a git repository
+func IsManifestURL(url string) bool {
+	if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		return false
+	}
+	if i := strings.IndexAny(url, "?#"); i >= 0 {
+		url = url[:i]
+	}
+	return strings.HasSuffix(url, ".json")
+}
+
+// Fetch performs a conditional GET using the previous ETag/Last-Modified.
+// Returns nil data if the server reports the manifest as not modified.
+func (f *Fetcher) Fetch(url string, prev FetchInfo) ([]byte, FetchInfo, error) {
+	if config.IsOffline() {
+		return nil, prev, config.ErrOffline
+	}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, prev, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if prev.ETag != "" {
+		req.Header.Set("If-None-Match", prev.ETag)
+	}
+	if prev.LastModified != "" {
+		req.Header.Set("If-Modified-Since", prev.LastModified)
+	}
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, prev, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		return nil, prev, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, prev, fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize+1))
+	if err != nil {
+		return nil, prev, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	if len(data) > maxManifestSize {
+		return nil, prev, fmt.Errorf("failed to fetch %s: manifest exceeds %d bytes", url, maxManifestSize)
+	}
+
+	sum := sha256.Sum256(data)
+	return data, FetchInfo{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Hash:         hex.EncodeToString(sum[:]),
+	}, nil
+}
+
+// HasUpdate checks whether the remote manifest differs from the stored one.
+// A 304 response means no update; otherwise the content hashes are compared.
+func (f *Fetcher) HasUpdate(url string, prev FetchInfo) (bool, FetchInfo, error) {
+	data, info, err := f.Fetch(url, prev)
+	if err != nil || data == nil {
+		return false, info, err
+	}
+	return info.Hash != prev.Hash, info, nil
+}
+
+// Update fetches the manifest and stores it in installLocation if it changed.
+// Returns the new caching state and whether the stored manifest was replaced.
+func (f *Fetcher) Update(url, installLocation string, prev FetchInfo) (FetchInfo, bool, error) {
+	data, info, err := f.Fetch(url, prev)
+	if err != nil {
+		return prev, false, err
+	}
+	if data == nil || info.Hash == prev.Hash {
+		// Keep the freshest validators even if the content is unchanged
+		return info, false, nil
+	}
+
+	if err := StoreManifest(installLocation, data); err != nil {
+		return prev, false, err
+	}
+	return info, true, nil
+}
+
+// StoreManifest validates a downloaded manifest and writes it to
+// installLocation/.claude-plugin/marketplace.json
+func StoreManifest(installLocation string, data []byte) error {
+	var manifest MarketplaceManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	manifestPath := filepath.Join(installLocation, ManifestDir, ManifestFile)
+	if err := os.MkdirAll(filepath.Dir(manifestPath), 0755); err != nil {
+		return err
+	}
+
+	// Write to a temp file first so a failed write never leaves a truncated manifest
+	tmpPath := manifestPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, manifestPath)
+}
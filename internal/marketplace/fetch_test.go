@@ -0,0 +1,142 @@
+package marketplace
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testManifest = `{"name":"test","owner":{"name":"x"},"plugins":[]}`
+
+// manifestServer serves testManifest with validators and answers conditional requests
+func manifestServer(t *testing.T, etag, lastModified string) (*httptest.Server, *[]*http.Request) {
+	t.Helper()
+	var requests []*http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		if etag != "" && r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if lastModified != "" && r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if etag != "" {
+			w.Header().Set("ETag", etag)
+		}
+		if lastModified != "" {
+			w.Header().Set("Last-Modified", lastModified)
+		}
+		w.Write([]byte(testManifest))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestFetchOK(t *testing.T) {
+	srv, _ := manifestServer(t, `"v1"`, "Mon, 12 Oct 2026 10:00:00 GMT")
+
+	data, info, err := NewFetcher().Fetch(srv.URL+"/marketplace.json", FetchInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != testManifest {
+		t.Errorf("data = %q, want %q", data, testManifest)
+	}
+	if info.ETag != `"v1"` || info.LastModified != "Mon, 12 Oct 2026 10:00:00 GMT" {
+		t.Errorf("validators = %+v, want the response headers", info)
+	}
+	if info.Hash == "" {
+		t.Error("hash is empty")
+	}
+}
+
+func TestFetchNotModified(t *testing.T) {
+	tests := []struct {
+		name string
+		etag string
+		lm   string
+		prev FetchInfo
+	}{
+		{name: "etag", etag: `"v1"`, prev: FetchInfo{ETag: `"v1"`, Hash: "h"}},
+		{name: "last-modified", lm: "Mon, 12 Oct 2026 10:00:00 GMT", prev: FetchInfo{LastModified: "Mon, 12 Oct 2026 10:00:00 GMT", Hash: "h"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := manifestServer(t, tt.etag, tt.lm)
+			f := NewFetcher()
+
+			data, info, err := f.Fetch(srv.URL+"/marketplace.json", tt.prev)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if data != nil {
+				t.Errorf("data = %q, want nil on 304", data)
+			}
+			if info != tt.prev {
+				t.Errorf("info = %+v, want previous %+v", info, tt.prev)
+			}
+			r := (*requests)[0]
+			if r.Header.Get("If-None-Match") != tt.prev.ETag || r.Header.Get("If-Modified-Since") != tt.prev.LastModified {
+				t.Errorf("conditional headers not sent: %v", r.Header)
+			}
+
+			hasUpdate, _, err := f.HasUpdate(srv.URL+"/marketplace.json", tt.prev)
+			if err != nil || hasUpdate {
+				t.Errorf("HasUpdate = %v, %v; want false, nil", hasUpdate, err)
+			}
+		})
+	}
+}
+
+func TestFetchErrorStatus(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusForbidden, http.StatusInternalServerError} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "nope", status)
+		}))
+		prev := FetchInfo{ETag: `"old"`}
+		data, info, err := NewFetcher().Fetch(srv.URL+"/marketplace.json", prev)
+		srv.Close()
+
+		if err == nil || !strings.Contains(err.Error(), http.StatusText(status)) {
+			t.Errorf("status %d: err = %v, want one mentioning the status", status, err)
+		}
+		if data != nil || info != prev {
+			t.Errorf("status %d: got data %q and info %+v, want nil and the previous info", status, data, info)
+		}
+	}
+}
+
+func TestFetchSizeLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte(" "), maxManifestSize+1))
+	}))
+	defer srv.Close()
+
+	_, _, err := NewFetcher().Fetch(srv.URL+"/marketplace.json", FetchInfo{})
+	if err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Fatalf("err = %v, want size limit error", err)
+	}
+}
+
+func TestUpdateStoresChangedManifest(t *testing.T) {
+	srv, _ := manifestServer(t, `"v2"`, "")
+	dir := t.TempDir()
+
+	info, changed, err := NewFetcher().Update(srv.URL+"/marketplace.json", dir, FetchInfo{ETag: `"v1"`, Hash: "old"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed || info.ETag != `"v2"` {
+		t.Errorf("Update = %+v, %v; want the new validators and changed", info, changed)
+	}
+	stored, err := os.ReadFile(filepath.Join(dir, ManifestDir, ManifestFile))
+	if err != nil || string(stored) != testManifest {
+		t.Errorf("stored manifest = %q, %v", stored, err)
+	}
+}
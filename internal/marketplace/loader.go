@@ -1,10 +1,14 @@
 package marketplace
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/egoavara/codex-market/internal/plugin"
 )
 
 const (
@@ -34,6 +38,16 @@ func LoadManifest(marketplacePath string) (*MarketplaceManifest, error) {
 	return &manifest, nil
 }
 
+// ManifestHash returns the SHA-256 of a marketplace's manifest file
+func ManifestHash(marketplacePath string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(marketplacePath, ManifestDir, ManifestFile))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
 // FindPlugin finds a plugin by name in the manifest
 func (m *MarketplaceManifest) FindPlugin(name string) *PluginEntry {
 	for i := range m.Plugins {
@@ -68,3 +82,36 @@ func (m *MarketplaceManifest) GetPluginSourcePath(marketplacePath string, plugin
 func (p *PluginEntry) IsRemoteSource() bool {
 	return p.Source.Type == "url" || p.Source.Type == "github"
 }
+
+// IsArchiveSource returns true if the plugin is distributed as a tar.gz or zip archive
+func (p *PluginEntry) IsArchiveSource() bool {
+	return p.Source.Type == "archive"
+}
+
+// IsStrict returns true unless the entry sets "strict": false.
+// Strict plugins must ship a plugin.json, which the entry supplements;
+// non-strict entries act as the whole manifest of plugins without one.
+func (p *PluginEntry) IsStrict() bool {
+	return p.Strict == nil || *p.Strict
+}
+
+// ComponentManifest returns the entry's metadata and component declarations
+// as a plugin manifest, for merging with the plugin's own plugin.json
+func (p *PluginEntry) ComponentManifest() *plugin.PluginManifest {
+	return &plugin.PluginManifest{
+		Name:         p.Name,
+		Version:      p.Version,
+		Description:  p.Description,
+		Homepage:     p.Homepage,
+		Repository:   p.Repository,
+		License:      p.License,
+		Keywords:     p.Keywords,
+		Commands:     p.Commands,
+		Agents:       p.Agents,
+		Skills:       p.Skills,
+		OutputStyles: p.OutputStyles,
+		Hooks:        p.Hooks,
+		MCPServers:   p.MCPServers,
+		LSPServers:   p.LSPServers,
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/egoavara/codex-market/internal/backup"
 	"github.com/egoavara/codex-market/internal/config"
 )
 
@@ -43,6 +44,12 @@ func (r *Registry) List() (KnownMarketplaces, error) {
 			Source:          MarketplaceSource(mp.Source),
 			InstallLocation: mp.InstallLocation,
 			LastUpdated:     mp.LastUpdated,
+			ManifestHash:    mp.ManifestHash,
+			ETag:            mp.ETag,
+			LastModified:    mp.LastModified,
+			Ref:             mp.Ref,
+			RefKind:         mp.RefKind,
+			ActiveURL:       mp.ActiveURL,
 		}
 	}
 
@@ -81,22 +88,28 @@ func (r *Registry) Get(name string) (*KnownMarketplace, error) {
 	return &mp, nil
 }
 
-// Add adds a new marketplace to the registry
-func (r *Registry) Add(name string, url string, installLocation string) error {
+// Add adds a new marketplace to the registry.
+// Directory and url marketplaces also record their manifest hash for change detection.
+func (r *Registry) Add(name string, source MarketplaceSource, installLocation string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	cfg := config.Get()
 
 	mp := config.Marketplace{
-		Source: config.MarketplaceSource{
-			Source: "git",
-			URL:    url,
-		},
+		Source:          config.MarketplaceSource(source),
 		InstallLocation: installLocation,
 		LastUpdated:     time.Now().Format(time.RFC3339),
 	}
 
+	if source.Source == SourceDirectory || source.Source == SourceURL {
+		hash, err := ManifestHash(installLocation)
+		if err != nil {
+			return err
+		}
+		mp.ManifestHash = hash
+	}
+
 	cfg.Marketplaces[name] = mp
 
 	if err := config.Save(cfg); err != nil {
@@ -105,7 +118,7 @@ func (r *Registry) Add(name string, url string, installLocation string) error {
 
 	// If sync mode, also update Claude's settings
 	if cfg.Claude.Registry.Share == config.ShareSync {
-		return syncToClaudeSettings(name, url)
+		return syncToClaudeSettings(name, source)
 	}
 
 	return nil
@@ -132,7 +145,9 @@ func (r *Registry) Remove(name string) error {
 	return nil
 }
 
-// UpdateTimestamp updates the last updated timestamp for a marketplace
+// UpdateTimestamp updates the last updated timestamp for a marketplace.
+// For directory marketplaces it also records the current manifest hash,
+// so the manifest counts as up to date until it changes again.
 func (r *Registry) UpdateTimestamp(name string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -141,6 +156,87 @@ func (r *Registry) UpdateTimestamp(name string) error {
 
 	if mp, ok := cfg.Marketplaces[name]; ok {
 		mp.LastUpdated = time.Now().Format(time.RFC3339)
+		if mp.Source.Source == SourceDirectory {
+			if hash, err := ManifestHash(mp.InstallLocation); err == nil {
+				mp.ManifestHash = hash
+			}
+		}
+		cfg.Marketplaces[name] = mp
+		return config.Save(cfg)
+	}
+
+	return nil
+}
+
+// SetFetchInfo records the HTTP caching state of a url marketplace
+// and updates its timestamp
+func (r *Registry) SetFetchInfo(name string, info FetchInfo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg := config.Get()
+
+	if mp, ok := cfg.Marketplaces[name]; ok {
+		mp.LastUpdated = time.Now().Format(time.RFC3339)
+		mp.ETag = info.ETag
+		mp.LastModified = info.LastModified
+		mp.ManifestHash = info.Hash
+		cfg.Marketplaces[name] = mp
+		return config.Save(cfg)
+	}
+
+	return nil
+}
+
+// SetPin records the ref a git marketplace is pinned to. An empty ref unpins it.
+func (r *Registry) SetPin(name, ref, kind string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg := config.Get()
+
+	if mp, ok := cfg.Marketplaces[name]; ok {
+		mp.Ref = ref
+		mp.RefKind = kind
+		if ref == "" {
+			mp.RefKind = ""
+		}
+		mp.LastUpdated = time.Now().Format(time.RFC3339)
+		cfg.Marketplaces[name] = mp
+		return config.Save(cfg)
+	}
+
+	return nil
+}
+
+// SetAuth stores the credentials of a marketplace; nil removes them
+func (r *Registry) SetAuth(name string, auth *config.MarketplaceAuth) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg := config.Get()
+
+	if mp, ok := cfg.Marketplaces[name]; ok {
+		mp.Auth = auth
+		cfg.Marketplaces[name] = mp
+		return config.Save(cfg)
+	}
+
+	return nil
+}
+
+// SetMirrors replaces the mirror URLs and host rewrites of a marketplace.
+// The last used URL is forgotten so the source URL is tried first again.
+func (r *Registry) SetMirrors(name string, mirrors []string, rewrites []config.HostRewrite) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg := config.Get()
+
+	if mp, ok := cfg.Marketplaces[name]; ok {
+		mp.Mirrors = mirrors
+		mp.Rewrites = rewrites
+		mp.ActiveURL = ""
 		cfg.Marketplaces[name] = mp
 		return config.Save(cfg)
 	}
@@ -175,12 +271,9 @@ func loadClaudeMarketplaces() (KnownMarketplaces, error) {
 }
 
 // syncToClaudeSettings adds a marketplace to Claude's settings.json
-func syncToClaudeSettings(name, url string) error {
+func syncToClaudeSettings(name string, source MarketplaceSource) error {
 	settingsPath := config.GlobalSettingsPath()
 
-	// Convert git SSH URL to HTTPS URL for Claude compatibility
-	httpsURL := convertToHTTPS(url)
-
 	// Load existing settings
 	data, err := os.ReadFile(settingsPath)
 	var settings map[string]interface{}
@@ -203,11 +296,21 @@ func syncToClaudeSettings(name, url string) error {
 	}
 
 	// Add marketplace
-	extra[name] = map[string]interface{}{
-		"source": map[string]interface{}{
-			"source": "url",
-			"url":    httpsURL,
-		},
+	if source.Source == SourceDirectory {
+		extra[name] = map[string]interface{}{
+			"source": map[string]interface{}{
+				"source": "directory",
+				"path":   source.Path,
+			},
+		}
+	} else {
+		// Convert git SSH URL to HTTPS URL for Claude compatibility
+		extra[name] = map[string]interface{}{
+			"source": map[string]interface{}{
+				"source": "url",
+				"url":    convertToHTTPS(source.URL),
+			},
+		}
 	}
 
 	settings["extraKnownMarketplaces"] = extra
@@ -222,7 +325,7 @@ func syncToClaudeSettings(name, url string) error {
 		return err
 	}
 
-	return os.WriteFile(settingsPath, newData, 0644)
+	return backup.WriteFile(settingsPath, newData, 0644)
 }
 
 // convertToHTTPS converts git SSH URL to HTTPS URL
@@ -274,5 +377,5 @@ func removeFromClaudeSettings(name string) error {
 		return err
 	}
 
-	return os.WriteFile(settingsPath, newData, 0644)
+	return backup.WriteFile(settingsPath, newData, 0644)
 }
@@ -0,0 +1,193 @@
+package marketplace
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DefaultPluginsDir is where new plugins are created inside a marketplace
+// that does not set metadata.pluginRoot
+const DefaultPluginsDir = "plugins"
+
+// InitManifest creates .claude-plugin/marketplace.json in dir with no plugins.
+// Fails if the manifest already exists.
+func InitManifest(dir, name, owner, description string) error {
+	if !isValidName(name) {
+		return fmt.Errorf("invalid marketplace name %q: must not contain whitespace, '/', '\\' or '@'", name)
+	}
+
+	manifestPath := filepath.Join(dir, ManifestDir, ManifestFile)
+	if _, err := os.Stat(manifestPath); err == nil {
+		return fmt.Errorf("marketplace already exists: %s", manifestPath)
+	}
+
+	manifest := MarketplaceManifest{
+		Name:    name,
+		Owner:   Owner{Name: owner},
+		Plugins: []PluginEntry{},
+	}
+	if description != "" {
+		manifest.Metadata = &MarketplaceMetadata{Description: description}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(manifestPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(manifestPath, append(data, '\n'), 0644)
+}
+
+// FindRoot walks up from dir looking for a marketplace manifest.
+// Returns the marketplace root, or "" if dir is not inside a marketplace.
+func FindRoot(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ManifestDir, ManifestFile)); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// PluginsDir returns the directory where plugins of the marketplace at root live
+func (m *MarketplaceManifest) PluginsDir(root string) string {
+	if m.Metadata != nil && m.Metadata.PluginRoot != "" {
+		return filepath.Join(root, m.Metadata.PluginRoot)
+	}
+	return filepath.Join(root, DefaultPluginsDir)
+}
+
+// RegisterPlugin appends a path-sourced plugin entry to the marketplace at root.
+// pluginPath is the plugin directory; the recorded source is relative to the
+// marketplace's pluginRoot. Other fields and key order in marketplace.json are kept.
+func RegisterPlugin(root, name, description, pluginPath string) error {
+	manifest, err := LoadManifest(root)
+	if err != nil {
+		return err
+	}
+	if manifest.FindPlugin(name) != nil {
+		return fmt.Errorf("plugin %q is already registered in %s", name, manifest.Name)
+	}
+
+	base := root
+	if manifest.Metadata != nil && manifest.Metadata.PluginRoot != "" {
+		base = filepath.Join(root, manifest.Metadata.PluginRoot)
+	}
+	rel, err := filepath.Rel(base, pluginPath)
+	if err != nil {
+		return err
+	}
+
+	entry, err := json.Marshal(struct {
+		Name        string `json:"name"`
+		Source      string `json:"source"`
+		Description string `json:"description,omitempty"`
+	}{name, "./" + filepath.ToSlash(rel), description})
+	if err != nil {
+		return err
+	}
+
+	manifestPath := filepath.Join(root, ManifestDir, ManifestFile)
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	keys, values, err := decodeOrderedObject(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	var plugins []json.RawMessage
+	if raw, ok := values["plugins"]; ok {
+		if err := json.Unmarshal(raw, &plugins); err != nil {
+			return fmt.Errorf("failed to parse manifest: plugins: %w", err)
+		}
+	} else {
+		keys = append(keys, "plugins")
+	}
+	plugins = append(plugins, entry)
+
+	if values["plugins"], err = json.Marshal(plugins); err != nil {
+		return err
+	}
+
+	out, err := encodeOrderedObject(keys, values)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(manifestPath, out, 0644)
+}
+
+// decodeOrderedObject decodes a JSON object keeping its key order
+func decodeOrderedObject(data []byte) ([]string, map[string]json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, nil, fmt.Errorf("expected a JSON object")
+	}
+
+	var keys []string
+	values := make(map[string]json.RawMessage)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("expected an object key")
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, nil, err
+		}
+		if _, exists := values[key]; !exists {
+			keys = append(keys, key)
+		}
+		values[key] = value
+	}
+
+	return keys, values, nil
+}
+
+// encodeOrderedObject encodes a JSON object with the given key order, indented with two spaces
+func encodeOrderedObject(keys []string, values map[string]json.RawMessage) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(values[key])
+	}
+	buf.WriteByte('}')
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
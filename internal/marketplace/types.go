@@ -2,7 +2,9 @@ package marketplace
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
+
+	"github.com/egoavara/codex-market/internal/plugin"
 )
 
 // MarketplaceManifest represents the .claude-plugin/marketplace.json structure
@@ -17,13 +19,24 @@ type MarketplaceManifest struct {
 // String format: "./plugins/xxx"
 // Object format (url): {"source": "url", "url": "https://..."}
 // Object format (github): {"source": "github", "repo": "owner/repo"}
+// Object format (archive): {"source": "archive", "url": "https://.../plugin.tar.gz", "sha256": "..."}
+// Git objects may add "ref" (branch or tag), "sha" (commit) and "path" (subfolder of the repository).
+// Archive URLs may also be paths relative to the marketplace; "path" selects a subfolder of the archive.
 type PluginSource struct {
-	Path string // local path (when source is a string)
-	Type string // "path", "url", or "github"
-	URL  string // git URL (when source is an object with type "url")
-	Repo string // GitHub repo in "owner/repo" format (when source is "github")
+	Path   string // local path (when source is a string)
+	Type   string // "path", "url", "github", or "archive"
+	URL    string // git URL (type "url"), or archive URL or path (type "archive")
+	Repo   string // GitHub repo in "owner/repo" format (when source is "github")
+	Ref    string // branch or tag to check out (git sources)
+	SHA    string // commit to check out; takes precedence over Ref (git sources)
+	Subdir string // plugin folder inside the repository or archive
+	SHA256 string // expected checksum of the archive (archive sources)
 }
 
+// ErrInvalidSource is returned when decoding a plugin source that is neither
+// a string nor an object
+var ErrInvalidSource = errors.New("invalid source format: expected string or object")
+
 // UnmarshalJSON implements custom JSON unmarshaling for PluginSource
 func (p *PluginSource) UnmarshalJSON(data []byte) error {
 	// Try string first
@@ -39,15 +52,23 @@ func (p *PluginSource) UnmarshalJSON(data []byte) error {
 		Source string `json:"source"`
 		URL    string `json:"url"`
 		Repo   string `json:"repo"`
+		Ref    string `json:"ref"`
+		SHA    string `json:"sha"`
+		Path   string `json:"path"`
+		SHA256 string `json:"sha256"`
 	}
 	if err := json.Unmarshal(data, &obj); err == nil {
 		p.Type = obj.Source
 		p.URL = obj.URL
 		p.Repo = obj.Repo
+		p.Ref = obj.Ref
+		p.SHA = obj.SHA
+		p.Subdir = obj.Path
+		p.SHA256 = obj.SHA256
 		return nil
 	}
 
-	return fmt.Errorf("invalid source format: expected string or object")
+	return ErrInvalidSource
 }
 
 // GetSourceURL returns the effective URL for the plugin source
@@ -62,6 +83,15 @@ func (p *PluginSource) GetSourceURL() string {
 	}
 }
 
+// Revision returns the commit or ref a git source should be checked out at,
+// or "" for the default branch
+func (p *PluginSource) Revision() string {
+	if p.SHA != "" {
+		return p.SHA
+	}
+	return p.Ref
+}
+
 // Owner represents the marketplace owner information
 type Owner struct {
 	Name  string `json:"name"`
@@ -79,16 +109,25 @@ type MarketplaceMetadata struct {
 type PluginEntry struct {
 	Name        string       `json:"name"`
 	Source      PluginSource `json:"source"`
-	Version     string   `json:"version,omitempty"`
-	Description string   `json:"description,omitempty"`
-	Author      *Owner   `json:"author,omitempty"`
-	Homepage    string   `json:"homepage,omitempty"`
-	Repository  string   `json:"repository,omitempty"`
-	License     string   `json:"license,omitempty"`
-	Keywords    []string `json:"keywords,omitempty"`
-	Category    string   `json:"category,omitempty"`
-	Tags        []string `json:"tags,omitempty"`
-	Strict      bool     `json:"strict,omitempty"`
+	Version     string       `json:"version,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Author      *Owner       `json:"author,omitempty"`
+	Homepage    string       `json:"homepage,omitempty"`
+	Repository  string       `json:"repository,omitempty"`
+	License     string       `json:"license,omitempty"`
+	Keywords    []string     `json:"keywords,omitempty"`
+	Category    string       `json:"category,omitempty"`
+	Tags        []string     `json:"tags,omitempty"`
+	Strict      *bool        `json:"strict,omitempty"` // default true
+
+	// Component declarations, relative to the plugin root (same format as plugin.json)
+	Commands     plugin.PathList     `json:"commands,omitempty"`
+	Agents       plugin.PathList     `json:"agents,omitempty"`
+	Skills       plugin.PathList     `json:"skills,omitempty"`
+	OutputStyles plugin.PathList     `json:"outputStyles,omitempty"`
+	Hooks        plugin.ComponentRef `json:"hooks,omitempty"`
+	MCPServers   plugin.ComponentRef `json:"mcpServers,omitempty"`
+	LSPServers   plugin.ComponentRef `json:"lspServers,omitempty"`
 }
 
 // KnownMarketplace represents an entry in known_marketplaces.json
@@ -96,14 +135,41 @@ type KnownMarketplace struct {
 	Source          MarketplaceSource `json:"source"`
 	InstallLocation string            `json:"installLocation"`
 	LastUpdated     string            `json:"lastUpdated"`
+	ManifestHash    string            `json:"manifestHash,omitempty"` // marketplace.json hash at last update (directory and url sources)
+	ETag            string            `json:"etag,omitempty"`         // HTTP ETag of the manifest (url sources)
+	LastModified    string            `json:"lastModified,omitempty"` // HTTP Last-Modified of the manifest (url sources)
+	Ref             string            `json:"ref,omitempty"`          // pinned branch, tag or commit (git sources)
+	RefKind         string            `json:"refKind,omitempty"`      // "branch", "tag" or "commit"
+	ActiveURL       string            `json:"activeUrl,omitempty"`    // mirror or source URL that last succeeded (git sources)
 }
 
+// Marketplace source types
+const (
+	// SourceGit marketplaces are cloned into the marketplaces directory
+	SourceGit = "git"
+	// SourceDirectory marketplaces are used in place from a local directory
+	SourceDirectory = "directory"
+	// SourceURL marketplaces are a marketplace.json fetched over HTTP(S)
+	SourceURL = "url"
+)
+
 // MarketplaceSource describes the source of a marketplace
 type MarketplaceSource struct {
-	Source string `json:"source"` // "git", "directory"
+	Source string `json:"source"` // "git", "directory", "url"
 	URL    string `json:"url,omitempty"`
 	Path   string `json:"path,omitempty"`
 }
 
+// FetchInfo returns the HTTP caching state recorded for a url marketplace
+func (m KnownMarketplace) FetchInfo() FetchInfo {
+	return FetchInfo{ETag: m.ETag, LastModified: m.LastModified, Hash: m.ManifestHash}
+}
+
+// IsPinnedToRevision returns true if the marketplace is pinned to a tag or commit,
+// which never moves on update (branch pins still follow their branch)
+func (m KnownMarketplace) IsPinnedToRevision() bool {
+	return m.Ref != "" && m.RefKind != "branch"
+}
+
 // KnownMarketplaces is a map of marketplace name to KnownMarketplace
 type KnownMarketplaces map[string]KnownMarketplace
@@ -0,0 +1,518 @@
+package marketplace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/egoavara/codex-market/internal/plugin"
+)
+
+// Severity is the severity of a validation problem
+type Severity string
+
+const (
+	// SeverityError marks problems that break installation
+	SeverityError Severity = "error"
+	// SeverityWarning marks problems that are tolerated but likely mistakes
+	SeverityWarning Severity = "warning"
+)
+
+// Problem is a single validation finding
+type Problem struct {
+	Severity Severity `json:"severity"`
+	File     string   `json:"file"`            // path relative to the marketplace root
+	Field    string   `json:"field,omitempty"` // JSON field or frontmatter key, if applicable
+	Message  string   `json:"message"`
+}
+
+func (p Problem) String() string {
+	loc := p.File
+	if p.Field != "" {
+		loc += ": " + p.Field
+	}
+	return fmt.Sprintf("%s: %s: %s", p.Severity, loc, p.Message)
+}
+
+// ValidationReport collects every problem found in a marketplace
+type ValidationReport struct {
+	Root     string    `json:"root"`
+	Plugins  int       `json:"plugins"`
+	Problems []Problem `json:"problems"`
+}
+
+// Errors returns the number of error-level problems
+func (r *ValidationReport) Errors() int {
+	n := 0
+	for _, p := range r.Problems {
+		if p.Severity == SeverityError {
+			n++
+		}
+	}
+	return n
+}
+
+// Warnings returns the number of warning-level problems
+func (r *ValidationReport) Warnings() int {
+	return len(r.Problems) - r.Errors()
+}
+
+func (r *ValidationReport) add(severity Severity, file, field, format string, args ...any) {
+	r.Problems = append(r.Problems, Problem{
+		Severity: severity,
+		File:     file,
+		Field:    field,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Validate lints a marketplace directory: marketplace.json against the
+// MarketplaceManifest schema, every local plugin's plugin.json, component
+// paths, SKILL.md frontmatter and MCP configs. It never stops at the first problem.
+func Validate(marketplacePath string) *ValidationReport {
+	report := &ValidationReport{Root: marketplacePath, Problems: []Problem{}}
+	manifestFile := filepath.ToSlash(filepath.Join(ManifestDir, ManifestFile))
+
+	data, err := os.ReadFile(filepath.Join(marketplacePath, ManifestDir, ManifestFile))
+	if err != nil {
+		report.add(SeverityError, manifestFile, "", "cannot read manifest: %v", err)
+		return report
+	}
+
+	// Decode loosely first so field-level problems can be reported individually
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		report.add(SeverityError, manifestFile, "", "invalid JSON: %v", err)
+		return report
+	}
+
+	checkUnknownFields(report, manifestFile, "", raw, MarketplaceManifest{}, "$schema", "description", "version")
+
+	var manifest MarketplaceManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &typeErr) && strings.HasPrefix(typeErr.Field, "plugins"):
+			// Plugin entries are decoded and reported individually below
+		case errors.As(err, &typeErr):
+			report.add(SeverityError, manifestFile, typeErr.Field, "expected %s, got %s", typeErr.Type, typeErr.Value)
+		case errors.Is(err, ErrInvalidSource):
+			// A plugin entry's source; reported with the entry below
+		default:
+			report.add(SeverityError, manifestFile, "", "%v", err)
+		}
+	}
+
+	if manifest.Name == "" {
+		report.add(SeverityError, manifestFile, "name", "is required")
+	} else if !isValidName(manifest.Name) {
+		report.add(SeverityError, manifestFile, "name", "%q must not contain whitespace, '/', '\\' or '@'", manifest.Name)
+	}
+	if manifest.Owner.Name == "" {
+		report.add(SeverityError, manifestFile, "owner.name", "is required")
+	}
+	if _, ok := raw["plugins"]; !ok {
+		report.add(SeverityError, manifestFile, "plugins", "is required")
+	}
+
+	// Re-decode plugins one by one so a bad entry doesn't hide the others
+	var rawPlugins []json.RawMessage
+	if p, ok := raw["plugins"]; ok {
+		if err := json.Unmarshal(p, &rawPlugins); err != nil {
+			report.add(SeverityError, manifestFile, "plugins", "must be an array")
+		}
+	}
+
+	seen := make(map[string]int)
+	for i, rawPlugin := range rawPlugins {
+		field := fmt.Sprintf("plugins[%d]", i)
+
+		var rawEntry map[string]json.RawMessage
+		if err := json.Unmarshal(rawPlugin, &rawEntry); err != nil {
+			report.add(SeverityError, manifestFile, field, "must be an object")
+			continue
+		}
+		checkUnknownFields(report, manifestFile, field, rawEntry, PluginEntry{})
+
+		var entry PluginEntry
+		if err := json.Unmarshal(rawPlugin, &entry); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) {
+				report.add(SeverityError, manifestFile, field+"."+typeErr.Field, "expected %s, got %s", typeErr.Type, typeErr.Value)
+			} else if errors.Is(err, ErrInvalidSource) {
+				report.add(SeverityError, manifestFile, field+".source", "%v", err)
+			} else {
+				report.add(SeverityError, manifestFile, field, "%v", err)
+			}
+			continue
+		}
+		report.Plugins++
+
+		if entry.Name == "" {
+			report.add(SeverityError, manifestFile, field+".name", "is required")
+		} else {
+			field = fmt.Sprintf("plugins[%d](%s)", i, entry.Name)
+			if !isValidName(entry.Name) {
+				report.add(SeverityError, manifestFile, field+".name", "%q must not contain whitespace, '/', '\\' or '@'", entry.Name)
+			}
+			if prev, ok := seen[entry.Name]; ok {
+				report.add(SeverityError, manifestFile, field+".name", "duplicate plugin name (also plugins[%d])", prev)
+			} else {
+				seen[entry.Name] = i
+			}
+		}
+
+		if _, ok := rawEntry["source"]; !ok {
+			report.add(SeverityError, manifestFile, field+".source", "is required")
+			continue
+		}
+		validatePluginEntry(report, marketplacePath, &manifest, &entry, manifestFile, field)
+	}
+
+	return report
+}
+
+// validatePluginEntry checks a plugin's source and, for local sources, its contents
+func validatePluginEntry(report *ValidationReport, root string, manifest *MarketplaceManifest, entry *PluginEntry, manifestFile, field string) {
+	switch entry.Source.Type {
+	case "path":
+		if entry.Source.Path == "" {
+			report.add(SeverityError, manifestFile, field+".source", "path must not be empty")
+			return
+		}
+	case "url":
+		if entry.Source.URL == "" {
+			report.add(SeverityError, manifestFile, field+".source.url", "is required for url sources")
+		}
+		validateGitSource(report, &entry.Source, manifestFile, field)
+		return // remote sources can't be checked without fetching them
+	case "github":
+		if entry.Source.Repo == "" || strings.Count(entry.Source.Repo, "/") != 1 {
+			report.add(SeverityError, manifestFile, field+".source.repo", "must be in \"owner/repo\" format")
+		}
+		validateGitSource(report, &entry.Source, manifestFile, field)
+		return
+	case "archive":
+		validateArchiveSource(report, root, manifest, entry, manifestFile, field)
+		return
+	default:
+		report.add(SeverityError, manifestFile, field+".source.source", "unknown source type %q", entry.Source.Type)
+		return
+	}
+
+	pluginPath := manifest.GetPluginSourcePath(root, entry)
+	info, err := os.Stat(pluginPath)
+	if err != nil || !info.IsDir() {
+		report.add(SeverityError, manifestFile, field+".source", "plugin directory %q not found", entry.Source.Path)
+		return
+	}
+
+	validatePluginDir(report, root, pluginPath, entry, manifestFile, field)
+}
+
+// validateGitSource checks the sha and subfolder of a git plugin source
+func validateGitSource(report *ValidationReport, source *PluginSource, manifestFile, field string) {
+	if source.SHA != "" && !commitSHAPattern.MatchString(source.SHA) {
+		report.add(SeverityError, manifestFile, field+".source.sha", "%q is not a commit SHA", source.SHA)
+	}
+	if source.SHA != "" && source.Ref != "" {
+		report.add(SeverityWarning, manifestFile, field+".source.ref", "ignored because sha is set")
+	}
+	if source.Subdir != "" {
+		subdir := filepath.Clean(filepath.FromSlash(source.Subdir))
+		if filepath.IsAbs(subdir) || subdir == ".." || strings.HasPrefix(subdir, ".."+string(filepath.Separator)) {
+			report.add(SeverityError, manifestFile, field+".source.path", "%q must be relative to the repository root", source.Subdir)
+		}
+	}
+}
+
+// validateArchiveSource checks the location and checksum of an archive plugin source.
+// Local archives must exist; their contents are not inspected.
+func validateArchiveSource(report *ValidationReport, root string, manifest *MarketplaceManifest, entry *PluginEntry, manifestFile, field string) {
+	source := &entry.Source
+	if source.URL == "" {
+		report.add(SeverityError, manifestFile, field+".source.url", "is required for archive sources")
+		return
+	}
+
+	if source.SHA256 != "" && !sha256Pattern.MatchString(source.SHA256) {
+		report.add(SeverityError, manifestFile, field+".source.sha256", "%q is not a SHA-256 checksum", source.SHA256)
+	} else if source.SHA256 == "" && IsRemoteArchive(source.URL) {
+		report.add(SeverityWarning, manifestFile, field+".source.sha256", "not set; the downloaded archive will not be verified")
+	}
+
+	if source.Subdir != "" {
+		subdir := filepath.Clean(filepath.FromSlash(source.Subdir))
+		if filepath.IsAbs(subdir) || subdir == ".." || strings.HasPrefix(subdir, ".."+string(filepath.Separator)) {
+			report.add(SeverityError, manifestFile, field+".source.path", "%q must be relative to the archive root", source.Subdir)
+		}
+	}
+
+	if IsRemoteArchive(source.URL) {
+		return
+	}
+	location, err := manifest.ArchiveLocation(&KnownMarketplace{InstallLocation: root}, entry)
+	if err != nil {
+		report.add(SeverityError, manifestFile, field+".source.url", "%v", err)
+		return
+	}
+	if info, err := os.Stat(location); err != nil || info.IsDir() {
+		report.add(SeverityError, manifestFile, field+".source.url", "archive %q not found", source.URL)
+		return
+	}
+	if source.SHA256 != "" && sha256Pattern.MatchString(source.SHA256) {
+		if sum, err := HashFile(location); err == nil && !strings.EqualFold(sum, source.SHA256) {
+			report.add(SeverityError, manifestFile, field+".source.sha256", "does not match %s (sha256 %s)", source.URL, sum)
+		}
+	}
+}
+
+// validatePluginDir checks plugin.json, component paths, skills, commands, agents and MCP configs
+func validatePluginDir(report *ValidationReport, root, pluginPath string, entry *PluginEntry, manifestFile, field string) {
+	rel := func(p string) string {
+		if r, err := filepath.Rel(root, p); err == nil {
+			return filepath.ToSlash(r)
+		}
+		return p
+	}
+	pluginManifestFile := rel(plugin.ManifestPath(pluginPath))
+
+	pluginManifest, err := plugin.LoadManifest(pluginPath)
+	if err != nil {
+		report.add(SeverityError, pluginManifestFile, "", "%v", err)
+		return
+	}
+
+	valid := true
+	if pluginManifest == nil {
+		if entry.IsStrict() {
+			report.add(SeverityError, pluginManifestFile, "", "not found; strict plugins must ship a plugin.json (or set \"strict\": false)")
+		}
+	} else {
+		for _, fe := range fieldErrors(pluginManifest.Validate()) {
+			report.add(SeverityError, pluginManifestFile, fe.Field, "%s", fe.Message)
+			valid = false
+		}
+		if pluginManifest.Name != "" && pluginManifest.Name != entry.Name {
+			report.add(SeverityWarning, pluginManifestFile, "name", "%q differs from marketplace entry name %q", pluginManifest.Name, entry.Name)
+		}
+	}
+
+	// Component paths declared in the marketplace entry are reported against the entry
+	entryManifest := entry.ComponentManifest()
+	for _, fe := range fieldErrors(entryManifest.Validate()) {
+		if fe.Field == "name" {
+			continue // already reported for the entry itself
+		}
+		report.add(SeverityError, manifestFile, field+"."+fe.Field, "%s", fe.Message)
+		valid = false
+	}
+	if !valid {
+		return
+	}
+
+	// Missing declared paths are attributed to whichever manifest provides the merged field
+	merged := plugin.MergeManifest(pluginManifest, entryManifest)
+	components, err := plugin.ResolveComponentsWithManifest(pluginPath, merged)
+	if err != nil {
+		file, prefix := pluginManifestFile, ""
+		if pluginManifest == nil {
+			file, prefix = manifestFile, field+"."
+		}
+		for _, fe := range fieldErrors(err) {
+			report.add(SeverityError, file, prefix+fe.Field, "%s", fe.Message)
+		}
+		return
+	}
+
+	// Skills need SKILL.md with name and description frontmatter
+	skillNames := make(map[string]string)
+	for _, skillsPath := range components.Skills {
+		dirs, err := plugin.FindSkillDirs(skillsPath)
+		if err != nil {
+			report.add(SeverityError, rel(skillsPath), "", "%v", err)
+			continue
+		}
+		for _, dir := range dirs {
+			skillFile := rel(filepath.Join(dir, "SKILL.md"))
+			data, err := os.ReadFile(filepath.Join(dir, "SKILL.md"))
+			if err != nil {
+				report.add(SeverityError, skillFile, "", "%v", err)
+				continue
+			}
+			fm, _, err := plugin.ParseFrontmatter(data)
+			if err != nil {
+				report.add(SeverityError, skillFile, "frontmatter", "%v", err)
+				continue
+			}
+			if fm.Len() == 0 {
+				report.add(SeverityError, skillFile, "frontmatter", "is missing")
+				continue
+			}
+			for _, key := range []string{"name", "description"} {
+				if fm.Get(key) == "" {
+					report.add(SeverityError, skillFile, key, "is required")
+				}
+			}
+			name := filepath.Base(dir)
+			if prev, ok := skillNames[name]; ok {
+				report.add(SeverityWarning, skillFile, "", "skill folder name %q is also used by %s", name, prev)
+			} else {
+				skillNames[name] = skillFile
+			}
+		}
+	}
+
+	// Command frontmatter must parse
+	for _, commandsPath := range components.Commands {
+		files, err := plugin.FindCommandFiles(commandsPath)
+		if err != nil {
+			report.add(SeverityError, rel(commandsPath), "", "%v", err)
+			continue
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file.Path)
+			if err != nil {
+				report.add(SeverityError, rel(file.Path), "", "%v", err)
+				continue
+			}
+			if _, _, err := plugin.ParseFrontmatter(data); err != nil {
+				report.add(SeverityError, rel(file.Path), "frontmatter", "%v", err)
+			}
+		}
+	}
+
+	// Agents need parseable frontmatter and a description
+	for _, agentsPath := range components.Agents {
+		files, err := plugin.FindMarkdownFiles(agentsPath)
+		if err != nil {
+			report.add(SeverityError, rel(agentsPath), "", "%v", err)
+			continue
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				report.add(SeverityError, rel(file), "", "%v", err)
+				continue
+			}
+			fm, _, err := plugin.ParseFrontmatter(data)
+			if err != nil {
+				report.add(SeverityError, rel(file), "frontmatter", "%v", err)
+				continue
+			}
+			if fm.Get("description") == "" {
+				report.add(SeverityWarning, rel(file), "description", "is missing")
+			}
+		}
+	}
+
+	// MCP configs must parse and must not declare a server twice
+	mcpValid := true
+	for _, mcpPath := range components.MCPConfigs {
+		var v map[string]json.RawMessage
+		if data, err := os.ReadFile(mcpPath); err != nil {
+			report.add(SeverityError, rel(mcpPath), "", "%v", err)
+			mcpValid = false
+		} else if err := json.Unmarshal(data, &v); err != nil {
+			report.add(SeverityError, rel(mcpPath), "", "invalid JSON: %v", err)
+			mcpValid = false
+		}
+	}
+	if mcpValid {
+		if _, err := plugin.LoadMCPServers(components); err != nil {
+			report.add(SeverityError, rel(pluginPath), "mcpServers", "%v", err)
+		}
+	}
+
+	// Hook configs must at least be valid JSON
+	for _, hooksPath := range components.Hooks {
+		data, err := os.ReadFile(hooksPath)
+		if err != nil {
+			report.add(SeverityError, rel(hooksPath), "", "%v", err)
+			continue
+		}
+		var v map[string]json.RawMessage
+		if err := json.Unmarshal(data, &v); err != nil {
+			report.add(SeverityError, rel(hooksPath), "", "invalid JSON: %v", err)
+		}
+	}
+}
+
+// checkUnknownFields warns about keys that the schema (json tags of v) does not define
+func checkUnknownFields(report *ValidationReport, file, prefix string, raw map[string]json.RawMessage, v any, extra ...string) {
+	known := make(map[string]bool)
+	for _, k := range extra {
+		known[k] = true
+	}
+	t := reflect.TypeOf(v)
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			known[name] = true
+		}
+	}
+
+	keys := make([]string, 0, len(raw))
+	for k := range raw {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if !known[k] {
+			field := k
+			if prefix != "" {
+				field = prefix + "." + k
+			}
+			report.add(SeverityWarning, file, field, "unknown field")
+		}
+	}
+}
+
+// fieldErrors flattens a joined validation error into field errors
+func fieldErrors(err error) []*plugin.FieldError {
+	if err == nil {
+		return nil
+	}
+
+	var errs []error
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	} else {
+		errs = []error{err}
+	}
+
+	var result []*plugin.FieldError
+	for _, e := range errs {
+		var fe *plugin.FieldError
+		if errors.As(e, &fe) {
+			result = append(result, fe)
+		} else {
+			result = append(result, &plugin.FieldError{Message: e.Error()})
+		}
+	}
+	return result
+}
+
+// commitSHAPattern matches full or abbreviated commit SHAs
+var commitSHAPattern = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+
+// isValidName checks that a marketplace or plugin name can be used in plugin@marketplace identifiers
+func isValidName(name string) bool {
+	return !strings.ContainsAny(name, " \t/\\@")
+}
+
+// ValidateName checks that a marketplace name taken from a manifest or URL is
+// safe to use as a directory name under the marketplaces and cache directories
+func ValidateName(name string) error {
+	if name == "" || !isValidName(name) || strings.Contains(name, "..") || name == "." {
+		return fmt.Errorf("invalid marketplace name %q: must be non-empty and must not contain whitespace, '/', '\\', '@' or '..'", name)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package marketplace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateReportsInvalidSourceOnEntry(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, ManifestDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	manifest := `{"name":"test","owner":{"name":"x"},"plugins":[{"name":"demo","source":123}]}`
+	if err := os.WriteFile(filepath.Join(dir, ManifestDir, ManifestFile), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report := Validate(dir)
+	if len(report.Problems) != 1 || report.Problems[0].Field != "plugins[0].source" {
+		t.Fatalf("problems = %v, want one for plugins[0].source", report.Problems)
+	}
+}
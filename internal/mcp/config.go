@@ -6,7 +6,10 @@ import (
 	"os"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+
+	"github.com/egoavara/codex-market/internal/backup"
 )
 
 const (
@@ -87,7 +90,7 @@ func AddMCPServers(configPath string, pluginName string, marketplace string, ser
 	}
 
 	// Write back to file
-	if err := os.WriteFile(configPath, []byte(newContent), 0644); err != nil {
+	if err := backup.WriteFile(configPath, []byte(newContent), 0644); err != nil {
 		return nil, fmt.Errorf("failed to write config file: %w", err)
 	}
 
@@ -106,7 +109,7 @@ func RemoveMCPServers(configPath string, pluginName string) error {
 
 	newContent := RemoveMarkedBlock(string(content), pluginName)
 
-	if err := os.WriteFile(configPath, []byte(newContent), 0644); err != nil {
+	if err := backup.WriteFile(configPath, []byte(newContent), 0644); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
@@ -158,13 +161,65 @@ func RemoveMarkedBlock(content string, pluginName string) string {
 	startPattern := regexp.QuoteMeta(fmt.Sprintf("%s plugin=%s", MarkerStartPrefix, pluginName))
 	endPattern := regexp.QuoteMeta(fmt.Sprintf("%s plugin=%s", MarkerEndPrefix, pluginName))
 
-	// Match from start marker line to end marker line (including newlines between)
-	fullPattern := fmt.Sprintf(`(?m)\n?%s[^\n]*\n(?:.*\n)*?%s\n?`, startPattern, endPattern)
+	// Match from start marker line to end marker line (including newlines between).
+	// The name must end at a space or line end, so "plugin=a" doesn't match "plugin=ab".
+	fullPattern := fmt.Sprintf(`(?m)\n?%s(?: [^\n]*)?\n(?:.*\n)*?%s(?:\n|$)`, startPattern, endPattern)
 
 	re := regexp.MustCompile(fullPattern)
 	return re.ReplaceAllString(content, "")
 }
 
+// MarkedBlock is a block of MCP servers in config.toml installed by codex-market
+type MarkedBlock struct {
+	Plugin      string   // plugin name from the start marker
+	Marketplace string   // marketplace name from the start marker
+	Servers     []string // MCP server names defined in the block
+}
+
+// mcpServerTablePattern matches an MCP server table header, e.g. [mcp_servers."name"]
+var mcpServerTablePattern = regexp.MustCompile(`^\[mcp_servers\.("(?:[^"\\]|\\.)*"|[A-Za-z0-9_-]+)\]$`)
+
+// MarkedBlocks returns the codex-market marker blocks in config.toml
+func MarkedBlocks(configPath string) ([]MarkedBlock, error) {
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var blocks []MarkedBlock
+	var current *MarkedBlock
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, MarkerStartPrefix+" "):
+			block := MarkedBlock{}
+			for _, field := range strings.Fields(strings.TrimPrefix(line, MarkerStartPrefix)) {
+				if v, ok := strings.CutPrefix(field, "plugin="); ok {
+					block.Plugin = v
+				} else if v, ok := strings.CutPrefix(field, "marketplace="); ok {
+					block.Marketplace = v
+				}
+			}
+			blocks = append(blocks, block)
+			current = &blocks[len(blocks)-1]
+		case strings.HasPrefix(line, MarkerEndPrefix):
+			current = nil
+		case current != nil:
+			if m := mcpServerTablePattern.FindStringSubmatch(line); m != nil {
+				name := m[1]
+				if unquoted, err := strconv.Unquote(name); err == nil {
+					name = unquoted
+				}
+				current.Servers = append(current.Servers, name)
+			}
+		}
+	}
+	return blocks, nil
+}
+
 // EnvVarMismatch represents a case where env key differs from referenced variable
 type EnvVarMismatch struct {
 	Key     string // The key name (e.g., "TEST")
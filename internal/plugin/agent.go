@@ -0,0 +1,106 @@
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxSkillDescriptionLength is the longest description Codex accepts in SKILL.md
+const maxSkillDescriptionLength = 500
+
+// agentNamePattern matches agent names that are safe to use as a skill folder name
+var agentNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// unsafeAgentNameChars matches characters replaced when deriving a name from the file name
+var unsafeAgentNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// Agent represents a Claude subagent definition (agents/*.md)
+type Agent struct {
+	Name         string // agent name (frontmatter name or file name)
+	Description  string // when the agent should be used
+	Tools        string // comma separated tool list, if restricted
+	Model        string // preferred model, if any
+	SystemPrompt string // markdown body used as the agent's system prompt
+}
+
+// LoadAgent parses a Claude agent definition file
+func LoadAgent(path string) (*Agent, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fm, body, err := ParseFrontmatter(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse agent %s: %w", filepath.Base(path), err)
+	}
+
+	agent := &Agent{
+		Name:         fm.Get("name"),
+		Description:  fm.Get("description"),
+		Tools:        strings.Join(fm.GetList("tools"), ", "),
+		Model:        fm.Get("model"),
+		SystemPrompt: strings.TrimSpace(body),
+	}
+	// The name becomes a skill folder name, so anything that could be a path
+	// (e.g. "../x") falls back to the file name
+	if !agentNamePattern.MatchString(agent.Name) {
+		agent.Name = strings.Trim(unsafeAgentNameChars.ReplaceAllString(
+			strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), "-"), "-")
+		if agent.Name == "" {
+			agent.Name = "agent"
+		}
+	}
+	if agent.Description == "" {
+		agent.Description = fmt.Sprintf("Act as the %s agent", agent.Name)
+	}
+
+	return agent, nil
+}
+
+// SkillMarkdown renders the agent as a Codex SKILL.md that wraps its system prompt
+func (a *Agent) SkillMarkdown(pluginID string) string {
+	description := strings.Join(strings.Fields(a.Description), " ")
+	if len(description) > maxSkillDescriptionLength {
+		// Cut on a rune boundary so multi-byte text stays valid UTF-8
+		cut := maxSkillDescriptionLength - 3
+		for cut > 0 && !utf8.RuneStart(description[cut]) {
+			cut--
+		}
+		description = strings.TrimSpace(description[:cut]) + "..."
+	}
+
+	fm := NewFrontmatter()
+	fm.Set("name", a.Name)
+	fm.Set("description", description)
+
+	var b strings.Builder
+	b.WriteString(fm.Render())
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "# %s\n\n", a.Name)
+	fmt.Fprintf(&b, "This skill was converted from the Claude agent `%s` (plugin `%s`).\n", a.Name, pluginID)
+	b.WriteString("When it applies, adopt the instructions below as your role for the task.\n")
+	if a.Tools != "" {
+		fmt.Fprintf(&b, "\nThe original agent was limited to these tools: %s.\n", a.Tools)
+	}
+	if a.Model != "" {
+		fmt.Fprintf(&b, "The original agent preferred the `%s` model.\n", a.Model)
+	}
+	b.WriteString("\n## Instructions\n\n")
+	b.WriteString(a.SystemPrompt)
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// ConvertAgentToSkill writes the agent as a skill folder at destPath
+func ConvertAgentToSkill(agent *Agent, pluginID, destPath string) error {
+	if err := os.MkdirAll(destPath, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(destPath, "SKILL.md"), []byte(agent.SkillMarkdown(pluginID)), 0644)
+}
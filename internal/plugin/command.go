@@ -0,0 +1,158 @@
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// codexPromptKeys are the frontmatter keys understood by Codex custom prompts
+var codexPromptKeys = map[string]bool{
+	"description":   true,
+	"argument-hint": true,
+}
+
+// claudeCommandKeyNotes explains Claude-only frontmatter keys that are dropped
+var claudeCommandKeyNotes = map[string]string{
+	"allowed-tools":            "tool permissions are not enforced by Codex prompts",
+	"model":                    "Codex prompts cannot select a model",
+	"disable-model-invocation": "Codex prompts are never invoked by the model",
+}
+
+var (
+	// namedPlaceholderPattern matches $NAME tokens that Codex treats as named arguments.
+	// A leading $ (already escaped) is captured so it can be preserved.
+	namedPlaceholderPattern = regexp.MustCompile(`\$?\$[A-Z][A-Z0-9_]*`)
+	// inlineBashPattern matches Claude's !`command` inline bash execution
+	inlineBashPattern = regexp.MustCompile("!`[^`]+`")
+)
+
+// TranslateCommand converts a Claude slash command into the Codex custom prompt format.
+// Frontmatter keys without a Codex equivalent are dropped, and $NAME tokens other than
+// $ARGUMENTS are escaped so Codex does not treat them as required named arguments.
+// Returns the translated content and a note for every behavior that could not be kept.
+func TranslateCommand(data []byte) ([]byte, []string, error) {
+	fm, body, err := ParseFrontmatter(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var notes []string
+
+	out := NewFrontmatter()
+	for _, key := range fm.Keys() {
+		if codexPromptKeys[key] {
+			out.Set(key, fm.Get(key))
+			continue
+		}
+		if note, ok := claudeCommandKeyNotes[key]; ok {
+			notes = append(notes, fmt.Sprintf("%s dropped: %s", key, note))
+		} else {
+			notes = append(notes, fmt.Sprintf("%s dropped: no Codex prompt equivalent", key))
+		}
+	}
+
+	body = namedPlaceholderPattern.ReplaceAllStringFunc(body, func(token string) string {
+		if strings.HasPrefix(token, "$$") || token == "$ARGUMENTS" {
+			return token
+		}
+		return "$" + token
+	})
+
+	if inlineBashPattern.MatchString(body) {
+		notes = append(notes, "inline bash (!`...`) is not executed by Codex")
+	}
+
+	var b strings.Builder
+	b.WriteString(out.Render())
+	b.WriteString(body)
+
+	return []byte(b.String()), notes, nil
+}
+
+// TranslateCommandFile translates a Claude command file and writes it to dst
+func TranslateCommandFile(src, dst string) ([]string, error) {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+
+	translated, notes, err := TranslateCommand(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to translate command: %w", err)
+	}
+
+	if err := os.WriteFile(dst, translated, info.Mode().Perm()); err != nil {
+		return nil, err
+	}
+
+	return notes, nil
+}
+
+// CommandNamespaceSeparator joins namespace segments when flattening nested
+// commands into the prompts directory (Claude shows them as "ns:name")
+const CommandNamespaceSeparator = "-"
+
+// CommandFile is a command definition found in a plugin
+type CommandFile struct {
+	Path string // absolute path to the .md file
+	Name string // Claude command name, with namespaces joined by ":" (e.g., "git:commit")
+}
+
+// PromptFileName returns the flattened prompt file name for the command (e.g., "git-commit.md")
+func (c CommandFile) PromptFileName() string {
+	return strings.ReplaceAll(c.Name, ":", CommandNamespaceSeparator) + ".md"
+}
+
+// FindCommandFiles returns command files at the given path. The path may be a
+// single .md file or a folder; subfolders become namespaces of their commands.
+func FindCommandFiles(path string) ([]CommandFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		if !strings.HasSuffix(path, ".md") {
+			return nil, nil
+		}
+		return []CommandFile{{Path: path, Name: strings.TrimSuffix(filepath.Base(path), ".md")}}, nil
+	}
+
+	var files []CommandFile
+	err = filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// Skip hidden folders such as .git
+			if p != path && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), ".md") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(path, p)
+		if err != nil {
+			return err
+		}
+		segments := strings.Split(filepath.ToSlash(strings.TrimSuffix(rel, ".md")), "/")
+		files = append(files, CommandFile{Path: p, Name: strings.Join(segments, ":")})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commands folder %s: %w", path, err)
+	}
+
+	return files, nil
+}
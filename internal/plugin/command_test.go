@@ -0,0 +1,93 @@
+package plugin
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite .golden files with the current output")
+
+// checkGolden compares got with testdata/<name>.golden, or rewrites it with -update
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("missing golden file (run go test -update): %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s mismatch\n--- got ---\n%s\n--- want ---\n%s", path, got, want)
+	}
+}
+
+func TestTranslateCommand(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "commands", "*.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no command fixtures found")
+	}
+
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".md")
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, notes, err := TranslateCommand(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The golden file holds the translated prompt followed by the notes
+			var b strings.Builder
+			b.Write(out)
+			b.WriteString("-- notes --\n")
+			for _, note := range notes {
+				b.WriteString(note + "\n")
+			}
+			checkGolden(t, filepath.Join("commands", name), b.String())
+		})
+	}
+}
+
+func TestFindCommandFiles(t *testing.T) {
+	root := filepath.Join("testdata", "command-tree")
+	files, err := FindCommandFiles(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	for _, f := range files {
+		rel, err := filepath.Rel(root, f.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(&b, "%s -> /%s (%s)\n", filepath.ToSlash(rel), f.Name, f.PromptFileName())
+	}
+	checkGolden(t, "command-tree", b.String())
+}
+
+func TestFindCommandFilesSingleFile(t *testing.T) {
+	path := filepath.Join("testdata", "command-tree", "git", "commit.md")
+	files, err := FindCommandFiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Name != "commit" || files[0].PromptFileName() != "commit.md" {
+		t.Fatalf("FindCommandFiles(%s) = %+v, want a single top-level commit command", path, files)
+	}
+}
@@ -0,0 +1,271 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/egoavara/codex-market/internal/mcp"
+)
+
+// CompatStatus describes how well a plugin component maps onto Codex
+type CompatStatus string
+
+const (
+	// CompatConverted means the component is installed with equivalent behavior
+	CompatConverted CompatStatus = "converted"
+	// CompatPartial means the component is installed but some behavior is lost
+	CompatPartial CompatStatus = "partial"
+	// CompatUnsupported means the component is not installed at all
+	CompatUnsupported CompatStatus = "unsupported"
+)
+
+// Component kinds used in compatibility reports
+const (
+	KindSkill       = "skill"
+	KindCommand     = "command"
+	KindAgent       = "agent"
+	KindMCPServer   = "mcpServer"
+	KindHook        = "hook"
+	KindLSPServer   = "lspServer"
+	KindOutputStyle = "outputStyle"
+)
+
+// CompatItem is the compatibility result for a single component
+type CompatItem struct {
+	Kind    string       `json:"kind"`
+	Name    string       `json:"name"`
+	Source  string       `json:"source"` // path relative to the plugin root
+	Status  CompatStatus `json:"status"`
+	Reasons []string     `json:"reasons,omitempty"`
+}
+
+// CompatReport lists the compatibility of every component in a plugin
+type CompatReport struct {
+	Plugin      string       `json:"plugin"`
+	Converted   int          `json:"converted"`
+	Partial     int          `json:"partial"`
+	Unsupported int          `json:"unsupported"`
+	Items       []CompatItem `json:"items"`
+}
+
+// add appends an item and updates the summary counts
+func (r *CompatReport) add(item CompatItem) {
+	if item.Status == CompatConverted && len(item.Reasons) > 0 {
+		item.Status = CompatPartial
+	}
+	switch item.Status {
+	case CompatConverted:
+		r.Converted++
+	case CompatPartial:
+		r.Partial++
+	case CompatUnsupported:
+		r.Unsupported++
+	}
+	r.Items = append(r.Items, item)
+}
+
+// HasIssues returns true if any component is partially converted or unsupported
+func (r *CompatReport) HasIssues() bool {
+	return r.Partial > 0 || r.Unsupported > 0
+}
+
+// AnalyzeCompatibility inspects resolved plugin components and reports how each
+// one is handled when installed into Codex
+func AnalyzeCompatibility(pluginID string, c *Components) (*CompatReport, error) {
+	report := &CompatReport{Plugin: pluginID, Items: []CompatItem{}}
+
+	rel := func(p string) string {
+		if r, err := filepath.Rel(c.Root, p); err == nil {
+			return filepath.ToSlash(r)
+		}
+		return p
+	}
+
+	// Skills are copied as-is
+	for _, skillsPath := range c.Skills {
+		dirs, err := FindSkillDirs(skillsPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, dir := range dirs {
+			item := CompatItem{Kind: KindSkill, Name: filepath.Base(dir), Source: rel(dir), Status: CompatConverted}
+			data, err := os.ReadFile(filepath.Join(dir, "SKILL.md"))
+			if err != nil {
+				return nil, err
+			}
+			fm, _, err := ParseFrontmatter(data)
+			if err != nil {
+				item.Reasons = append(item.Reasons, fmt.Sprintf("SKILL.md frontmatter is invalid: %v", err))
+			} else if fm.Has("allowed-tools") {
+				item.Reasons = append(item.Reasons, "allowed-tools is not enforced by Codex")
+			}
+			report.add(item)
+		}
+	}
+
+	// Commands are translated into Codex custom prompts
+	for _, commandsPath := range c.Commands {
+		files, err := FindCommandFiles(commandsPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			item := CompatItem{Kind: KindCommand, Name: file.Name, Source: rel(file.Path), Status: CompatConverted}
+			data, err := os.ReadFile(file.Path)
+			if err != nil {
+				return nil, err
+			}
+			if _, notes, err := TranslateCommand(data); err != nil {
+				item.Status = CompatUnsupported
+				item.Reasons = append(item.Reasons, fmt.Sprintf("frontmatter is invalid: %v", err))
+			} else {
+				item.Reasons = append(item.Reasons, notes...)
+			}
+			report.add(item)
+		}
+	}
+
+	// Agents are wrapped into skills
+	for _, agentsPath := range c.Agents {
+		files, err := FindMarkdownFiles(agentsPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			agent, err := LoadAgent(file)
+			if err != nil {
+				report.add(CompatItem{Kind: KindAgent, Name: filepath.Base(file), Source: rel(file), Status: CompatUnsupported, Reasons: []string{err.Error()}})
+				continue
+			}
+			item := CompatItem{Kind: KindAgent, Name: agent.Name, Source: rel(file), Status: CompatPartial,
+				Reasons: []string{"converted to a skill; Codex has no subagents"}}
+			if agent.Tools != "" {
+				item.Reasons = append(item.Reasons, "tool restrictions are not enforced")
+			}
+			if agent.Model != "" {
+				item.Reasons = append(item.Reasons, "model selection is ignored")
+			}
+			report.add(item)
+		}
+	}
+
+	// MCP servers are written to config.toml
+	if set, err := LoadMCPServers(c); err != nil {
+		report.add(CompatItem{Kind: KindMCPServer, Name: "(all)", Source: "mcpServers", Status: CompatUnsupported, Reasons: []string{err.Error()}})
+	} else {
+		for _, name := range sortedKeys(set.Servers) {
+			report.add(analyzeMCPServer(name, set.Sources[name], set.Servers[name]))
+		}
+	}
+
+	// Hooks have no Codex equivalent
+	for _, hooksPath := range c.Hooks {
+		data, err := os.ReadFile(hooksPath)
+		if err != nil {
+			return nil, err
+		}
+		addHookItems(report, rel(hooksPath), data)
+	}
+	if c.Manifest != nil && len(c.Manifest.Hooks.Inline) > 0 {
+		addHookItems(report, ManifestDir+"/"+ManifestFile, c.Manifest.Hooks.Inline)
+	}
+	if c.Manifest != nil && len(c.Manifest.Hooks.EntryInline) > 0 {
+		addHookItems(report, "marketplace entry", c.Manifest.Hooks.EntryInline)
+	}
+
+	// LSP servers have no Codex equivalent
+	for _, lspPath := range c.LSPConfigs {
+		data, err := os.ReadFile(lspPath)
+		if err != nil {
+			return nil, err
+		}
+		addNamedUnsupported(report, KindLSPServer, rel(lspPath), data, "Codex does not support LSP servers")
+	}
+	if c.Manifest != nil && len(c.Manifest.LSPServers.Inline) > 0 {
+		addNamedUnsupported(report, KindLSPServer, ManifestDir+"/"+ManifestFile, c.Manifest.LSPServers.Inline, "Codex does not support LSP servers")
+	}
+	if c.Manifest != nil && len(c.Manifest.LSPServers.EntryInline) > 0 {
+		addNamedUnsupported(report, KindLSPServer, "marketplace entry", c.Manifest.LSPServers.EntryInline, "Codex does not support LSP servers")
+	}
+
+	// Output styles have no Codex equivalent
+	for _, stylesPath := range c.OutputStyles {
+		files, err := FindMarkdownFiles(stylesPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			report.add(CompatItem{Kind: KindOutputStyle, Name: strings.TrimSuffix(filepath.Base(file), ".md"), Source: rel(file),
+				Status: CompatUnsupported, Reasons: []string{"Codex does not support output styles"}})
+		}
+	}
+
+	return report, nil
+}
+
+// analyzeMCPServer reports Claude-specific settings that are lost in config.toml
+func analyzeMCPServer(name, source string, server mcp.MCPServerConfig) CompatItem {
+	item := CompatItem{Kind: KindMCPServer, Name: name, Source: source, Status: CompatConverted}
+
+	if server.Cwd != "" {
+		item.Reasons = append(item.Reasons, "cwd is not supported by Codex")
+	}
+
+	_, mismatches := mcp.GenerateMCPServerTOML("", "", map[string]mcp.MCPServerConfig{name: server})
+	for _, m := range mismatches {
+		item.Reasons = append(item.Reasons, fmt.Sprintf("env %s references %s; Codex only forwards same-name variables", m.Key, m.VarName))
+	}
+
+	return item
+}
+
+// addHookItems adds one unsupported item per hook event in a hooks configuration
+func addHookItems(report *CompatReport, source string, data []byte) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		report.add(CompatItem{Kind: KindHook, Name: filepath.Base(source), Source: source, Status: CompatUnsupported, Reasons: []string{err.Error()}})
+		return
+	}
+
+	// hooks.json wraps events in a "hooks" object; inline configs may not
+	if inner, ok := raw["hooks"]; ok {
+		var events map[string]json.RawMessage
+		if err := json.Unmarshal(inner, &events); err == nil {
+			raw = events
+		}
+	}
+
+	for _, event := range sortedKeys(raw) {
+		if event == "description" {
+			continue
+		}
+		report.add(CompatItem{Kind: KindHook, Name: event, Source: source, Status: CompatUnsupported,
+			Reasons: []string{"Codex does not support hooks"}})
+	}
+}
+
+// addNamedUnsupported adds one unsupported item per top-level key of a JSON object
+func addNamedUnsupported(report *CompatReport, kind, source string, data []byte, reason string) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil || len(raw) == 0 {
+		report.add(CompatItem{Kind: kind, Name: filepath.Base(source), Source: source, Status: CompatUnsupported, Reasons: []string{reason}})
+		return
+	}
+	for _, name := range sortedKeys(raw) {
+		report.add(CompatItem{Kind: kind, Name: name, Source: source, Status: CompatUnsupported, Reasons: []string{reason}})
+	}
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,203 @@
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Default component locations relative to the plugin root
+const (
+	DefaultSkillsDir   = "skills"
+	DefaultCommandsDir = "commands"
+	DefaultAgentsDir   = "agents"
+	DefaultMCPFile     = ".mcp.json"
+	DefaultHooksFile   = "hooks/hooks.json"
+	DefaultLSPFile     = ".lsp.json"
+)
+
+// Components holds the resolved absolute locations of a plugin's components.
+// Paths declared in plugin.json supplement the default locations.
+type Components struct {
+	Root       string
+	Manifest   *PluginManifest // nil if the plugin has no plugin.json
+	Skills     []string        // skill folders or folders containing skill folders
+	Commands   []string        // command files or folders of command files
+	Agents     []string        // agent files or folders of agent files
+	MCPConfigs []string        // .mcp.json style files
+	// Components below have no Codex equivalent; they are only resolved for reporting
+	Hooks        []string // hooks.json style files
+	LSPConfigs   []string // .lsp.json style files
+	OutputStyles []string // output style files or folders
+}
+
+// ResolveComponents loads and validates the plugin manifest (if any) and
+// resolves every component location declared in it or found at the defaults
+func ResolveComponents(pluginPath string) (*Components, error) {
+	manifest, err := LoadManifest(pluginPath)
+	if err != nil {
+		return nil, err
+	}
+	if manifest != nil {
+		if err := manifest.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid plugin manifest %s:\n%w", ManifestPath(pluginPath), err)
+		}
+	}
+
+	return ResolveComponentsWithManifest(pluginPath, manifest)
+}
+
+// ResolveComponentsForEntry resolves components like ResolveComponents, but also
+// applies component declarations from the plugin's marketplace entry, which
+// supplement plugin.json. Strict entries require a plugin.json; for non-strict
+// entries it may be absent, and the entry is then the whole manifest.
+func ResolveComponentsForEntry(pluginPath string, entry *PluginManifest, strict bool) (*Components, error) {
+	manifest, err := LoadManifest(pluginPath)
+	if err != nil {
+		return nil, err
+	}
+	if manifest == nil && strict {
+		return nil, fmt.Errorf("%s not found; strict plugins must ship a plugin.json (or set \"strict\": false in the marketplace entry)", ManifestPath(pluginPath))
+	}
+
+	merged := MergeManifest(manifest, entry)
+	if merged != nil {
+		if err := merged.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid plugin manifest for %s:\n%w", pluginPath, err)
+		}
+	}
+
+	return ResolveComponentsWithManifest(pluginPath, merged)
+}
+
+// ResolveComponentsWithManifest resolves component locations using an already loaded manifest
+func ResolveComponentsWithManifest(pluginPath string, manifest *PluginManifest) (*Components, error) {
+	c := &Components{
+		Root:     pluginPath,
+		Manifest: manifest,
+	}
+
+	// A nil manifest behaves like an empty one: only default locations are used
+	m := manifest
+	if m == nil {
+		m = &PluginManifest{}
+	}
+
+	var err error
+	if c.Skills, err = resolvePaths(pluginPath, "skills", DefaultSkillsDir, m.Skills); err != nil {
+		return nil, err
+	}
+	if c.Commands, err = resolvePaths(pluginPath, "commands", DefaultCommandsDir, m.Commands); err != nil {
+		return nil, err
+	}
+	if c.Agents, err = resolvePaths(pluginPath, "agents", DefaultAgentsDir, m.Agents); err != nil {
+		return nil, err
+	}
+	if c.MCPConfigs, err = resolvePaths(pluginPath, "mcpServers", DefaultMCPFile, m.MCPServers.Paths); err != nil {
+		return nil, err
+	}
+	if c.Hooks, err = resolvePaths(pluginPath, "hooks", DefaultHooksFile, m.Hooks.Paths); err != nil {
+		return nil, err
+	}
+	if c.LSPConfigs, err = resolvePaths(pluginPath, "lspServers", DefaultLSPFile, m.LSPServers.Paths); err != nil {
+		return nil, err
+	}
+	if c.OutputStyles, err = resolvePaths(pluginPath, "outputStyles", "", m.OutputStyles); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// resolvePaths returns the default location (if it exists) followed by every
+// declared location. Declared locations must exist.
+func resolvePaths(root, field, defaultPath string, declared []string) ([]string, error) {
+	var result []string
+	seen := make(map[string]bool)
+
+	add := func(p string) {
+		if !seen[p] {
+			seen[p] = true
+			result = append(result, p)
+		}
+	}
+
+	if defaultPath != "" {
+		p := filepath.Join(root, defaultPath)
+		if _, err := os.Stat(p); err == nil {
+			add(p)
+		}
+	}
+
+	for _, d := range declared {
+		if err := validateRelativePath(d); err != nil {
+			return nil, &FieldError{Field: field, Message: err.Error()}
+		}
+		p := filepath.Join(root, filepath.FromSlash(d))
+		if _, err := os.Stat(p); err != nil {
+			return nil, &FieldError{Field: field, Message: fmt.Sprintf("declared path %q not found in plugin", d)}
+		}
+		add(p)
+	}
+
+	return result, nil
+}
+
+// FindSkillDirs returns skill folders (folders containing SKILL.md) at the given path.
+// The path may be a skill folder itself or a folder containing skill folders.
+func FindSkillDirs(path string) ([]string, error) {
+	if _, err := os.Stat(filepath.Join(path, "SKILL.md")); err == nil {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read skills folder: %w", err)
+	}
+
+	var dirs []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		skillPath := filepath.Join(path, entry.Name())
+		if _, err := os.Stat(filepath.Join(skillPath, "SKILL.md")); os.IsNotExist(err) {
+			continue // Skip directories without SKILL.md
+		}
+		dirs = append(dirs, skillPath)
+	}
+
+	return dirs, nil
+}
+
+// FindMarkdownFiles returns markdown files at the given path.
+// The path may be a single .md file or a folder of .md files.
+func FindMarkdownFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		if strings.HasSuffix(path, ".md") {
+			return []string{path}, nil
+		}
+		return nil, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read folder %s: %w", path, err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") {
+			continue
+		}
+		files = append(files, filepath.Join(path, entry.Name()))
+	}
+
+	return files, nil
+}
@@ -0,0 +1,71 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveComponentsForEntry(t *testing.T) {
+	tests := []struct {
+		name       string
+		strict     bool
+		pluginJSON string
+		wantSkills []string // relative to the plugin root
+		wantErr    string
+	}{
+		{
+			name:       "strict with plugin.json",
+			strict:     true,
+			pluginJSON: `{"name":"demo","skills":"./extra-skills"}`,
+			wantSkills: []string{"skills", "extra-skills", "entry-skills"},
+		},
+		{
+			name:    "strict without plugin.json",
+			strict:  true,
+			wantErr: "strict plugins must ship a plugin.json",
+		},
+		{
+			name:       "non-strict with plugin.json",
+			pluginJSON: `{"name":"demo","skills":"./extra-skills"}`,
+			wantSkills: []string{"skills", "extra-skills", "entry-skills"},
+		},
+		{
+			name:       "non-strict without plugin.json",
+			wantSkills: []string{"skills", "entry-skills"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := newTestPlugin(t, tt.pluginJSON)
+			for _, skill := range []string{"skills/a", "extra-skills/b", "entry-skills/c"} {
+				if err := os.MkdirAll(filepath.Join(dir, skill), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			entry := &PluginManifest{Name: "demo", Skills: PathList{"./entry-skills"}}
+
+			c, err := ResolveComponentsForEntry(dir, entry, tt.strict)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, p := range c.Skills {
+				rel, _ := filepath.Rel(dir, p)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantSkills, ",") {
+				t.Errorf("skills = %v, want %v", got, tt.wantSkills)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// frontmatterDelimiter marks the start and end of a markdown frontmatter block
+const frontmatterDelimiter = "---"
+
+// Frontmatter holds the flat key/value pairs of a markdown frontmatter block.
+// Only the simple subset used by Claude plugin files is supported:
+// scalar values, block lists (joined with ", ") and indented continuation lines.
+// Flow lists ([a, b]) are kept verbatim; use GetList to split them.
+type Frontmatter struct {
+	keys   []string
+	values map[string]string
+}
+
+// NewFrontmatter creates an empty frontmatter block
+func NewFrontmatter() *Frontmatter {
+	return &Frontmatter{values: make(map[string]string)}
+}
+
+// ParseFrontmatter splits a markdown document into its frontmatter and body.
+// Documents without frontmatter return an empty Frontmatter and the full content as body.
+func ParseFrontmatter(data []byte) (*Frontmatter, string, error) {
+	fm := NewFrontmatter()
+	content := strings.ReplaceAll(string(data), "\r\n", "\n")
+
+	lines := strings.Split(content, "\n")
+	if len(lines) < 2 || strings.TrimRight(lines[0], " ") != frontmatterDelimiter {
+		return fm, content, nil
+	}
+
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimRight(lines[i], " ") == frontmatterDelimiter {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return nil, "", fmt.Errorf("unterminated frontmatter block")
+	}
+
+	block := lines[1:end]
+	body := strings.Join(lines[end+1:], "\n")
+
+	var lastKey string
+	blockStyle := make(map[string]string) // original scalar indicator per key ("|", ">", ...)
+	for i, line := range block {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		// Block list item belonging to the previous key
+		if strings.HasPrefix(trimmed, "- ") && lastKey != "" {
+			item := unquoteFrontmatterValue(strings.TrimSpace(trimmed[2:]))
+			if prev := fm.values[lastKey]; prev != "" {
+				fm.values[lastKey] = prev + ", " + item
+			} else {
+				fm.values[lastKey] = item
+			}
+			continue
+		}
+
+		// Indented continuation of the previous value (block scalars, wrapped text)
+		if lastKey != "" && (line[0] == ' ' || line[0] == '\t') {
+			switch prev := fm.values[lastKey]; prev {
+			case "|", "|-", ">", ">-", "":
+				fm.values[lastKey] = trimmed
+			default:
+				sep := " "
+				if strings.HasPrefix(blockStyle[lastKey], "|") {
+					sep = "\n"
+				}
+				fm.values[lastKey] = prev + sep + trimmed
+			}
+			continue
+		}
+
+		idx := strings.Index(line, ":")
+		if idx <= 0 {
+			return nil, "", fmt.Errorf("invalid frontmatter line %d: %q", i+2, line)
+		}
+
+		key := strings.TrimSpace(line[:idx])
+		value := unquoteFrontmatterValue(strings.TrimSpace(line[idx+1:]))
+		fm.Set(key, value)
+		blockStyle[key] = value
+		lastKey = key
+	}
+
+	return fm, body, nil
+}
+
+// unquoteFrontmatterValue strips surrounding quotes from a scalar value
+func unquoteFrontmatterValue(v string) string {
+	if len(v) >= 2 {
+		switch {
+		case v[0] == '"' && v[len(v)-1] == '"':
+			if s, err := strconv.Unquote(v); err == nil {
+				return s
+			}
+			return v[1 : len(v)-1]
+		case v[0] == '\'' && v[len(v)-1] == '\'':
+			return strings.ReplaceAll(v[1:len(v)-1], "''", "'")
+		}
+	}
+	return v
+}
+
+// Keys returns the frontmatter keys in document order
+func (f *Frontmatter) Keys() []string {
+	return append([]string(nil), f.keys...)
+}
+
+// Has reports whether the key is present
+func (f *Frontmatter) Has(key string) bool {
+	_, ok := f.values[key]
+	return ok
+}
+
+// Get returns the value for a key, or "" if absent
+func (f *Frontmatter) Get(key string) string {
+	return f.values[key]
+}
+
+// GetList returns a list value, accepting flow lists ([a, b]), block lists
+// and comma separated scalars
+func (f *Frontmatter) GetList(key string) []string {
+	v := strings.TrimSpace(f.values[key])
+	if strings.HasPrefix(v, "[") && strings.HasSuffix(v, "]") {
+		v = v[1 : len(v)-1]
+	}
+
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, unquoteFrontmatterValue(item))
+		}
+	}
+	return items
+}
+
+// Set sets a key, keeping its original position if it already exists
+func (f *Frontmatter) Set(key, value string) {
+	if _, ok := f.values[key]; !ok {
+		f.keys = append(f.keys, key)
+	}
+	f.values[key] = value
+}
+
+// Delete removes a key
+func (f *Frontmatter) Delete(key string) {
+	if _, ok := f.values[key]; !ok {
+		return
+	}
+	delete(f.values, key)
+	for i, k := range f.keys {
+		if k == key {
+			f.keys = append(f.keys[:i], f.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// Len returns the number of keys
+func (f *Frontmatter) Len() int {
+	return len(f.keys)
+}
+
+// Render renders the frontmatter block including delimiters.
+// Returns "" if there are no keys.
+func (f *Frontmatter) Render() string {
+	if len(f.keys) == 0 {
+		return ""
+	}
+
+	var b bytes.Buffer
+	b.WriteString(frontmatterDelimiter + "\n")
+	for _, k := range f.keys {
+		fmt.Fprintf(&b, "%s: %s\n", k, quoteFrontmatterValue(f.values[k]))
+	}
+	b.WriteString(frontmatterDelimiter + "\n")
+	return b.String()
+}
+
+// quoteFrontmatterValue quotes a value when it would not round-trip as a plain YAML scalar
+func quoteFrontmatterValue(v string) string {
+	if v == "" {
+		return `""`
+	}
+	if strings.ContainsAny(v, ":#\n\"'") || strings.ContainsAny(v[:1], "[]{}&*!|>%@`-? ") || strings.HasSuffix(v, " ") {
+		return strconv.Quote(v)
+	}
+	return v
+}
@@ -2,6 +2,7 @@ package plugin
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"sync"
 
@@ -13,6 +14,69 @@ var (
 	installedOnce sync.Once
 )
 
+// installedMigrations upgrade installed.json one schema version at a time;
+// installedMigrations[i] upgrades version i to i+1
+var installedMigrations = []config.Migration{
+	// 0 -> 1: files without a version field have the version 1 layout
+	func(doc map[string]any) error {
+		return nil
+	},
+	// 1 -> 2: version 1 entries predate namespaced commands, agents and
+	// resolved commits. Fill in what later code expects of every entry.
+	func(doc map[string]any) error {
+		plugins, _ := doc["plugins"].(map[string]any)
+		for pluginID, raw := range plugins {
+			entries, ok := raw.([]any)
+			if !ok {
+				return fmt.Errorf("plugin %s: expected a list of installations", pluginID)
+			}
+			for _, e := range entries {
+				entry, ok := e.(map[string]any)
+				if !ok {
+					return fmt.Errorf("plugin %s: expected an installation object", pluginID)
+				}
+				if scope, _ := entry["scope"].(string); scope == "" {
+					entry["scope"] = "global"
+				}
+				// Commands were installed under their own name
+				for _, c := range objectList(entry["commands"]) {
+					if source, _ := c["source"].(string); source == "" {
+						c["source"] = c["name"]
+					}
+				}
+				// MCP servers are matched to config.toml markers by plugin ID
+				for _, s := range objectList(entry["mcpServers"]) {
+					if plugin, _ := s["plugin"].(string); plugin == "" {
+						s["plugin"] = pluginID
+					}
+				}
+			}
+		}
+		return nil
+	},
+	// 2 -> 3: entries record the hashes of their installed files. Older
+	// entries have none, so local changes to them go undetected until they
+	// are reinstalled; older codex-market versions would drop the hashes.
+	func(doc map[string]any) error {
+		return nil
+	},
+}
+
+// InstalledVersion is the schema version of installed.json written by this version
+var InstalledVersion = len(installedMigrations)
+
+// objectList returns the objects in a JSON array value
+func objectList(v any) []map[string]any {
+	items, _ := v.([]any)
+	var objects []map[string]any
+	for _, item := range items {
+		if obj, ok := item.(map[string]any); ok {
+			objects = append(objects, obj)
+		}
+	}
+	return objects
+}
+
 // InstalledManager manages installed plugins
 type InstalledManager struct {
 	mu   sync.RWMutex
@@ -42,6 +106,12 @@ func (m *InstalledManager) Load() (*InstalledPlugins, error) {
 		return nil, err
 	}
 
+	// Upgrade files written by older versions
+	data, err = config.MigrateJSON(m.path, data, installedMigrations)
+	if err != nil {
+		return nil, err
+	}
+
 	var plugins InstalledPlugins
 	if err := json.Unmarshal(data, &plugins); err != nil {
 		return nil, err
@@ -63,12 +133,18 @@ func (m *InstalledManager) Save(plugins *InstalledPlugins) error {
 		return err
 	}
 
+	// Never drop what a newer codex-market stored; keep files of an older one
+	if err := config.PrepareWrite(m.path, InstalledVersion); err != nil {
+		return err
+	}
+	plugins.Version = InstalledVersion
+
 	data, err := json.MarshalIndent(plugins, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(m.path, data, 0644)
+	return config.WriteFileAtomic(m.path, data, 0644)
 }
 
 // Add adds a new installed plugin entry
@@ -0,0 +1,141 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/egoavara/codex-market/internal/config"
+)
+
+// installedFixtures returns the installed.json files of every schema version
+// in testdata/installed, keyed by fixture name
+func installedFixtures(t *testing.T) map[string][]byte {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join("testdata", "installed", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixtures := make(map[string][]byte)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fixtures[strings.TrimSuffix(filepath.Base(path), ".json")] = data
+	}
+	// Every version up to the current one needs a fixture
+	for v := 0; v <= InstalledVersion; v++ {
+		if _, ok := fixtures[fmt.Sprintf("v%d", v)]; !ok {
+			t.Fatalf("no fixture for installed.json schema version %d", v)
+		}
+	}
+	return fixtures
+}
+
+func TestInstalledMigrations(t *testing.T) {
+	for name, data := range installedFixtures(t) {
+		t.Run(name, func(t *testing.T) {
+			migrated, err := config.MigrateJSON(name+".json", data, installedMigrations)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, filepath.Join("installed", name), string(migrated)+"\n")
+
+			// Migrating again changes nothing
+			again, err := config.MigrateJSON(name+".json", migrated, installedMigrations)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(again, migrated) {
+				t.Errorf("second migration changed the document:\n%s", again)
+			}
+		})
+	}
+}
+
+func TestInstalledRoundTrip(t *testing.T) {
+	for name, data := range installedFixtures(t) {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "installed.json")
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			m := &InstalledManager{path: path}
+
+			loaded, err := m.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if loaded.Version != InstalledVersion {
+				t.Errorf("loaded version = %d, want %d", loaded.Version, InstalledVersion)
+			}
+
+			// Loading doesn't write; the upgrade is stored by the next save
+			if onDisk, _ := os.ReadFile(path); !bytes.Equal(onDisk, data) {
+				t.Errorf("Load rewrote the file:\n%s", onDisk)
+			}
+			if backups, _ := filepath.Glob(path + ".v*.bak"); len(backups) > 0 {
+				t.Errorf("Load wrote backups %v", backups)
+			}
+
+			if err := m.Save(loaded); err != nil {
+				t.Fatal(err)
+			}
+			reloaded, err := m.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(reloaded, loaded) {
+				t.Errorf("round trip changed the entries:\n got %+v\nwant %+v", reloaded, loaded)
+			}
+
+			var saved struct {
+				Version int `json:"version"`
+			}
+			onDisk, _ := os.ReadFile(path)
+			if err := json.Unmarshal(onDisk, &saved); err != nil || saved.Version != InstalledVersion {
+				t.Errorf("saved version = %d, %v; want %d", saved.Version, err, InstalledVersion)
+			}
+
+			// The original of an older version is kept next to the file
+			backups, _ := filepath.Glob(path + ".v*.bak")
+			if name == fmt.Sprintf("v%d", InstalledVersion) {
+				if len(backups) > 0 {
+					t.Errorf("Save backed up a current file: %v", backups)
+				}
+			} else if len(backups) != 1 {
+				t.Errorf("backups = %v, want the original", backups)
+			} else if original, _ := os.ReadFile(backups[0]); !bytes.Equal(original, data) {
+				t.Errorf("backup %s doesn't hold the original", backups[0])
+			}
+		})
+	}
+}
+
+func TestInstalledSaveRefusesNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "installed.json")
+	newer := []byte(`{"version": 99, "plugins": {}, "futureField": true}`)
+	if err := os.WriteFile(path, newer, 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := &InstalledManager{path: path}
+
+	loaded, err := m.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schemaErr *config.NewerSchemaError
+	if err := m.Save(loaded); !errors.As(err, &schemaErr) {
+		t.Fatalf("Save = %v, want a NewerSchemaError", err)
+	}
+	if onDisk, _ := os.ReadFile(path); !bytes.Equal(onDisk, newer) {
+		t.Errorf("newer file was overwritten:\n%s", onDisk)
+	}
+}
@@ -0,0 +1,157 @@
+package plugin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/egoavara/codex-market/internal/config"
+)
+
+// LocalChange is an installed file the user changed after it was installed
+type LocalChange struct {
+	Path     string
+	Added    bool   // created by the user inside an installed skill folder
+	Original string // SHA-256 of the contents as installed; empty if Added
+}
+
+// RecordInstalledFiles hashes every file under the given installed paths and
+// keeps a copy of each in the originals store, so later edits can be detected
+// and merged with an update. Copies new to the store are journaled in tx.
+func RecordInstalledFiles(tx *Transaction, paths []string) ([]InstalledFile, error) {
+	var files []InstalledFile
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			sum := hashContent(data)
+			if err := saveOriginal(tx, sum, data); err != nil {
+				return err
+			}
+			files = append(files, InstalledFile{Path: path, SHA256: sum})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to record installed files: %w", err)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// DetectLocalChanges returns the installed files of an entry that were edited,
+// and files the user added to its skill folders. Entries installed before file
+// hashes were recorded have no detectable changes.
+func DetectLocalChanges(entry InstalledPluginEntry) ([]LocalChange, error) {
+	if len(entry.Files) == 0 {
+		return nil, nil
+	}
+
+	var changes []LocalChange
+	recorded := make(map[string]bool, len(entry.Files))
+	for _, f := range entry.Files {
+		recorded[f.Path] = true
+		data, err := os.ReadFile(f.Path)
+		if os.IsNotExist(err) {
+			continue // deleted files have nothing to keep
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hashContent(data) != f.SHA256 {
+			changes = append(changes, LocalChange{Path: f.Path, Original: f.SHA256})
+		}
+	}
+
+	// Uninstalling removes whole skill folders, including files the user added
+	var dirs []string
+	for _, s := range entry.Skills {
+		dirs = append(dirs, s.Path)
+	}
+	for _, a := range entry.Agents {
+		dirs = append(dirs, a.Path)
+	}
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if d.Type().IsRegular() && !recorded[path] {
+				changes = append(changes, LocalChange{Path: path, Added: true})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
+}
+
+// ReadOriginal returns installed file contents by their SHA-256
+func ReadOriginal(sum string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(config.OriginalsDir(), sum))
+}
+
+// PruneOriginals removes stored contents no installed entry refers to anymore
+func PruneOriginals(installed *InstalledPlugins) error {
+	inUse := make(map[string]bool)
+	for _, entries := range installed.Plugins {
+		for _, e := range entries {
+			for _, f := range e.Files {
+				inUse[f.SHA256] = true
+			}
+		}
+	}
+
+	dirEntries, err := os.ReadDir(config.OriginalsDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, d := range dirEntries {
+		if !inUse[d.Name()] {
+			if err := os.Remove(filepath.Join(config.OriginalsDir(), d.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// saveOriginal stores contents under their hash unless already stored
+func saveOriginal(tx *Transaction, sum string, data []byte) error {
+	path := filepath.Join(config.OriginalsDir(), sum)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := tx.Created(path); err != nil {
+		return err
+	}
+	if err := config.EnsureDir(config.OriginalsDir()); err != nil {
+		return err
+	}
+	return config.WriteFileAtomic(path, data, 0644)
+}
+
+func hashContent(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
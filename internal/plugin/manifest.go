@@ -0,0 +1,159 @@
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// ManifestDir is the directory containing plugin.json
+	ManifestDir = ".claude-plugin"
+	// ManifestFile is the plugin manifest filename
+	ManifestFile = "plugin.json"
+)
+
+// FieldError describes a problem with a single manifest field
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ManifestPath returns the plugin.json path for the given plugin directory
+func ManifestPath(pluginPath string) string {
+	return filepath.Join(pluginPath, ManifestDir, ManifestFile)
+}
+
+// LoadManifest loads the plugin manifest from the given plugin directory.
+// Returns (nil, nil) if the plugin has no plugin.json, since the manifest is optional.
+func LoadManifest(pluginPath string) (*PluginManifest, error) {
+	manifestPath := ManifestPath(pluginPath)
+
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read plugin manifest: %w", err)
+	}
+
+	var manifest PluginManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse plugin manifest %s: %w", manifestPath, err)
+	}
+
+	return &manifest, nil
+}
+
+// Validate checks the manifest for missing names and invalid component paths.
+// All problems are reported together; each one is a *FieldError.
+func (m *PluginManifest) Validate() error {
+	var errs []error
+
+	if m.Name == "" {
+		errs = append(errs, &FieldError{Field: "name", Message: "is required"})
+	} else if strings.ContainsAny(m.Name, " \t/\\@") {
+		errs = append(errs, &FieldError{Field: "name", Message: fmt.Sprintf("%q must not contain whitespace, '/', '\\' or '@'", m.Name)})
+	}
+
+	checkPaths := func(field string, paths []string) {
+		for i, p := range paths {
+			if err := validateRelativePath(p); err != nil {
+				name := field
+				if len(paths) > 1 {
+					name = fmt.Sprintf("%s[%d]", field, i)
+				}
+				errs = append(errs, &FieldError{Field: name, Message: err.Error()})
+			}
+		}
+	}
+
+	checkPaths("commands", m.Commands)
+	checkPaths("agents", m.Agents)
+	checkPaths("skills", m.Skills)
+	checkPaths("outputStyles", m.OutputStyles)
+	checkPaths("hooks", m.Hooks.Paths)
+	checkPaths("mcpServers", m.MCPServers.Paths)
+	checkPaths("lspServers", m.LSPServers.Paths)
+
+	return errors.Join(errs...)
+}
+
+// validateRelativePath ensures a component path stays inside the plugin root
+func validateRelativePath(p string) error {
+	if p == "" {
+		return fmt.Errorf("path must not be empty")
+	}
+	if filepath.IsAbs(p) || strings.HasPrefix(p, "/") {
+		return fmt.Errorf("path %q must be relative to the plugin root", p)
+	}
+	clean := filepath.Clean(filepath.FromSlash(p))
+	if clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("path %q must not point outside the plugin root", p)
+	}
+	return nil
+}
+
+// MergeManifest combines a plugin's own manifest with component declarations
+// from its marketplace entry: the entry's components supplement plugin.json,
+// and without a plugin.json the entry is the whole manifest.
+// Either manifest may be nil.
+func MergeManifest(base, entry *PluginManifest) *PluginManifest {
+	if base == nil && entry == nil {
+		return nil
+	}
+	if base == nil {
+		merged := *entry
+		return &merged
+	}
+
+	merged := *base
+	if entry == nil {
+		return &merged
+	}
+
+	if merged.Name == "" {
+		merged.Name = entry.Name
+	}
+	if merged.Version == "" {
+		merged.Version = entry.Version
+	}
+	if merged.Description == "" {
+		merged.Description = entry.Description
+	}
+
+	mergeList := func(dst *PathList, src PathList) {
+		if len(src) == 0 {
+			return
+		}
+		*dst = append(append(PathList(nil), *dst...), src...)
+	}
+	mergeRef := func(dst *ComponentRef, src ComponentRef) {
+		if src.IsEmpty() {
+			return
+		}
+		dst.Paths = append(append([]string(nil), dst.Paths...), src.Paths...)
+		if len(dst.Inline) == 0 {
+			dst.Inline = src.Inline
+		} else {
+			dst.EntryInline = src.Inline
+		}
+	}
+
+	mergeList(&merged.Commands, entry.Commands)
+	mergeList(&merged.Agents, entry.Agents)
+	mergeList(&merged.Skills, entry.Skills)
+	mergeList(&merged.OutputStyles, entry.OutputStyles)
+	mergeRef(&merged.Hooks, entry.Hooks)
+	mergeRef(&merged.MCPServers, entry.MCPServers)
+	mergeRef(&merged.LSPServers, entry.LSPServers)
+
+	return &merged
+}
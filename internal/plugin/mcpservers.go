@@ -0,0 +1,85 @@
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/egoavara/codex-market/internal/mcp"
+)
+
+// Labels of servers declared inline in plugin.json or the marketplace entry
+const (
+	inlineMCPSource = "inline mcpServers"
+	entryMCPSource  = "marketplace entry mcpServers"
+)
+
+// MCPServerSet is the merged set of MCP servers declared by a plugin
+type MCPServerSet struct {
+	Servers map[string]mcp.MCPServerConfig
+	Sources map[string]string // server name -> declaring file (relative to plugin root) or inline label
+}
+
+// LoadMCPServers merges MCP servers from every resolved config file and from
+// the inline mcpServers objects of plugin.json and the marketplace entry.
+// A server name declared twice is an error.
+func LoadMCPServers(c *Components) (*MCPServerSet, error) {
+	set := &MCPServerSet{
+		Servers: make(map[string]mcp.MCPServerConfig),
+		Sources: make(map[string]string),
+	}
+
+	add := func(source string, servers map[string]mcp.MCPServerConfig) error {
+		for _, name := range sortedKeys(servers) {
+			if prev, ok := set.Sources[name]; ok {
+				return fmt.Errorf("duplicate MCP server %q declared in %s and %s", name, prev, source)
+			}
+			set.Servers[name] = servers[name]
+			set.Sources[name] = source
+		}
+		return nil
+	}
+
+	for _, mcpPath := range c.MCPConfigs {
+		source := mcpPath
+		if rel, err := filepath.Rel(c.Root, mcpPath); err == nil {
+			source = filepath.ToSlash(rel)
+		}
+
+		data, err := os.ReadFile(mcpPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", source, err)
+		}
+		servers, err := mcp.ParseMCPJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+		if err := add(source, servers); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.Manifest != nil {
+		inline := []struct {
+			source string
+			data   []byte
+		}{
+			{inlineMCPSource, c.Manifest.MCPServers.Inline},
+			{entryMCPSource, c.Manifest.MCPServers.EntryInline},
+		}
+		for _, in := range inline {
+			if len(in.data) == 0 {
+				continue
+			}
+			servers, err := mcp.ParseMCPJSON(in.data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", in.source, err)
+			}
+			if err := add(in.source, servers); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return set, nil
+}
@@ -0,0 +1,88 @@
+package plugin
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestPlugin creates a plugin directory with the given plugin.json, if any
+func newTestPlugin(t *testing.T, pluginJSON string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if pluginJSON != "" {
+		if err := os.MkdirAll(filepath.Join(dir, ManifestDir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(ManifestPath(dir), []byte(pluginJSON), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadMCPServersInlineSources(t *testing.T) {
+	tests := []struct {
+		name       string
+		pluginJSON string
+		entry      string
+		want       []string // server names, or the error substring if wantErr
+		wantErr    bool
+	}{
+		{
+			name:       "both declare the same server",
+			pluginJSON: `{"name":"demo","mcpServers":{"db":{"command":"plugin-db"}}}`,
+			entry:      `{"mcpServers":{"db":{"command":"entry-db"}}}`,
+			want:       []string{`duplicate MCP server "db" declared in inline mcpServers and marketplace entry mcpServers`},
+			wantErr:    true,
+		},
+		{
+			name:       "distinct servers are merged",
+			pluginJSON: `{"name":"demo","mcpServers":{"db":{"command":"plugin-db"}}}`,
+			entry:      `{"mcpServers":{"search":{"url":"https://example.com/mcp"}}}`,
+			want:       []string{"db", "search"},
+		},
+		{
+			name:       "invalid entry object is reported",
+			pluginJSON: `{"name":"demo","mcpServers":{"db":{"command":"plugin-db"}}}`,
+			entry:      `{"mcpServers":{"search":{"command":42}}}`,
+			want:       []string{"marketplace entry mcpServers"},
+			wantErr:    true,
+		},
+		{
+			name:  "entry only",
+			entry: `{"name":"demo","mcpServers":{"search":{"url":"https://example.com/mcp"}}}`,
+			want:  []string{"search"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := newTestPlugin(t, tt.pluginJSON)
+			var entry PluginManifest
+			if err := json.Unmarshal([]byte(tt.entry), &entry); err != nil {
+				t.Fatal(err)
+			}
+			components, err := ResolveComponentsForEntry(dir, &entry, tt.pluginJSON != "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			set, err := LoadMCPServers(components)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), tt.want[0]) {
+					t.Fatalf("err = %v, want one containing %q", err, tt.want[0])
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sortedKeys(set.Servers); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("servers = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
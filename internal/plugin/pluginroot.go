@@ -0,0 +1,102 @@
+package plugin
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/egoavara/codex-market/internal/mcp"
+)
+
+const (
+	// PluginRootVar is the placeholder Claude expands to the plugin's install directory
+	PluginRootVar = "${CLAUDE_PLUGIN_ROOT}"
+	// PluginRootEnv is the environment variable Claude sets for plugin processes
+	PluginRootEnv = "CLAUDE_PLUGIN_ROOT"
+)
+
+// ExpandPluginRoot replaces ${CLAUDE_PLUGIN_ROOT} in s with root
+func ExpandPluginRoot(s, root string) string {
+	return strings.ReplaceAll(s, PluginRootVar, filepath.ToSlash(root))
+}
+
+// ExpandMCPServerRoot substitutes the plugin root in every command, argument,
+// URL and env value. Servers that referenced the placeholder also receive a
+// CLAUDE_PLUGIN_ROOT env var so their scripts can locate plugin files.
+func ExpandMCPServerRoot(servers map[string]mcp.MCPServerConfig, root string) map[string]mcp.MCPServerConfig {
+	result := make(map[string]mcp.MCPServerConfig, len(servers))
+
+	for name, server := range servers {
+		usesRoot := false
+		expand := func(s string) string {
+			if strings.Contains(s, PluginRootVar) {
+				usesRoot = true
+				return ExpandPluginRoot(s, root)
+			}
+			return s
+		}
+
+		server.Command = expand(server.Command)
+		server.URL = expand(server.URL)
+		server.Cwd = expand(server.Cwd)
+
+		if len(server.Args) > 0 {
+			args := make([]string, len(server.Args))
+			for i, arg := range server.Args {
+				args[i] = expand(arg)
+			}
+			server.Args = args
+		}
+
+		env := make(map[string]string, len(server.Env)+1)
+		for k, v := range server.Env {
+			env[k] = expand(v)
+		}
+		if usesRoot && server.Command != "" {
+			if _, ok := env[PluginRootEnv]; !ok {
+				env[PluginRootEnv] = filepath.ToSlash(root)
+			}
+		}
+		if len(env) > 0 {
+			server.Env = env
+		}
+
+		result[name] = server
+	}
+
+	return result
+}
+
+// ExpandPluginRootInFile rewrites ${CLAUDE_PLUGIN_ROOT} in a text file.
+// Binary files (containing NUL bytes) and files without the placeholder are left untouched.
+func ExpandPluginRootInFile(path, root string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if bytes.IndexByte(data, 0) >= 0 || !bytes.Contains(data, []byte(PluginRootVar)) {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, []byte(ExpandPluginRoot(string(data), root)), info.Mode().Perm())
+}
+
+// ExpandPluginRootInDir rewrites ${CLAUDE_PLUGIN_ROOT} in every text file under dir
+func ExpandPluginRootInDir(dir, root string) error {
+	return filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+		return ExpandPluginRootInFile(path, root)
+	})
+}
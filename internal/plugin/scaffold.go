@@ -0,0 +1,108 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ScaffoldOptions controls what Scaffold generates
+type ScaffoldOptions struct {
+	Name        string
+	Description string
+	Author      string
+	WithMCP     bool // also generate an example .mcp.json
+}
+
+// Scaffold creates a new plugin at dir with plugin.json, an example skill,
+// an example command and optionally an example .mcp.json.
+// Returns the created files relative to dir. Fails if dir already contains files.
+func Scaffold(dir string, opts ScaffoldOptions) ([]string, error) {
+	manifest := &PluginManifest{
+		Name:        opts.Name,
+		Version:     "0.1.0",
+		Description: opts.Description,
+	}
+	if opts.Author != "" {
+		manifest.Author = &Author{Name: opts.Author}
+	}
+	if err := manifest.Validate(); err != nil {
+		return nil, err
+	}
+
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return nil, fmt.Errorf("directory is not empty: %s", dir)
+	}
+
+	// Marshal through a plain struct so empty component fields are left out
+	manifestData, err := json.MarshalIndent(struct {
+		Name        string  `json:"name"`
+		Version     string  `json:"version"`
+		Description string  `json:"description,omitempty"`
+		Author      *Author `json:"author,omitempty"`
+	}{manifest.Name, manifest.Version, manifest.Description, manifest.Author}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	description := opts.Description
+	if description == "" {
+		description = "Example skill for the " + opts.Name + " plugin"
+	}
+
+	files := []struct {
+		path string
+		data string
+	}{
+		{filepath.Join(ManifestDir, ManifestFile), string(manifestData) + "\n"},
+		{filepath.Join(DefaultSkillsDir, "hello", "SKILL.md"), NewFrontmatter().withValues(
+			"name", "hello",
+			"description", description+". Use when the user asks for a greeting.",
+		).Render() + "\n# Hello\n\nGreet the user and briefly explain what the " + opts.Name + " plugin provides.\n"},
+		{filepath.Join(DefaultCommandsDir, "hello.md"), NewFrontmatter().withValues(
+			"description", "Say hello from "+opts.Name,
+			"argument-hint", "[name]",
+		).Render() + "\nSay hello to $1 and mention that this prompt comes from the " + opts.Name + " plugin.\n"},
+	}
+
+	if opts.WithMCP {
+		mcpData, err := json.MarshalIndent(map[string]any{
+			"mcpServers": map[string]any{
+				opts.Name: map[string]any{
+					"command": "npx",
+					"args":    []string{"-y", "@modelcontextprotocol/server-everything"},
+				},
+			},
+		}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, struct {
+			path string
+			data string
+		}{DefaultMCPFile, string(mcpData) + "\n"})
+	}
+
+	created := make([]string, 0, len(files))
+	for _, f := range files {
+		path := filepath.Join(dir, f.path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, []byte(f.data), 0644); err != nil {
+			return nil, err
+		}
+		created = append(created, filepath.ToSlash(f.path))
+	}
+
+	return created, nil
+}
+
+// withValues sets key/value pairs in order and returns the frontmatter
+func (f *Frontmatter) withValues(kv ...string) *Frontmatter {
+	for i := 0; i+1 < len(kv); i += 2 {
+		f.Set(kv[i], kv[i+1])
+	}
+	return f
+}
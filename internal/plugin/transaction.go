@@ -0,0 +1,240 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	"github.com/egoavara/codex-market/internal/config"
+)
+
+// ErrInterrupted is returned by a transaction step after Ctrl-C was pressed
+var ErrInterrupted = errors.New("interrupted")
+
+// Transaction journals the filesystem and config.toml changes made while
+// installing or uninstalling a plugin, so that a failed or interrupted
+// operation can be undone. Changes are kept once Commit is called; until then
+// Rollback reverts them in reverse order.
+type Transaction struct {
+	mu          sync.Mutex
+	journal     []txStep
+	trashDir    string // holds removed files until commit
+	onCommit    []func()
+	interrupted bool
+	done        bool
+	signals     chan os.Signal
+}
+
+// txStep is a journaled change and how to undo it
+type txStep struct {
+	desc string
+	undo func() error
+}
+
+// BeginTransaction starts a transaction. Ctrl-C is caught until the
+// transaction ends and makes the next step fail with ErrInterrupted.
+func BeginTransaction() *Transaction {
+	tx := &Transaction{signals: make(chan os.Signal, 1)}
+	signal.Notify(tx.signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		for range tx.signals {
+			tx.mu.Lock()
+			tx.interrupted = true
+			tx.mu.Unlock()
+		}
+	}()
+	return tx
+}
+
+// Check returns ErrInterrupted if the operation was interrupted
+func (tx *Transaction) Check() error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.interrupted {
+		return ErrInterrupted
+	}
+	return nil
+}
+
+// Created records that path was created; rollback removes it, along with any
+// parent directories created for it. Call it before writing to path, so
+// partially written paths are covered.
+func (tx *Transaction) Created(path string) error {
+	if err := tx.Check(); err != nil {
+		return err
+	}
+	// Only what didn't exist yet is ours to remove
+	if _, err := os.Lstat(path); err == nil {
+		return nil
+	}
+	top := path
+	for {
+		parent := filepath.Dir(top)
+		if parent == top {
+			break
+		}
+		if _, err := os.Lstat(parent); err == nil {
+			break
+		}
+		top = parent
+	}
+	tx.record("create "+top, func() error {
+		return os.RemoveAll(top)
+	})
+	return nil
+}
+
+// Modified records the current contents of a file about to be rewritten;
+// rollback restores them, or removes the file (and any parent directories
+// created for it) if it didn't exist.
+func (tx *Transaction) Modified(path string) error {
+	if err := tx.Check(); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return tx.Created(path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tx.record("modify "+path, func() error {
+		return config.WriteFileAtomic(path, data, info.Mode().Perm())
+	})
+	return nil
+}
+
+// Remove moves a file or directory out of the way; rollback puts it back and
+// Commit deletes it. A path that doesn't exist is ignored.
+func (tx *Transaction) Remove(path string) error {
+	if err := tx.Check(); err != nil {
+		return err
+	}
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	trash, err := tx.trashPath()
+	if err != nil {
+		return err
+	}
+	if err := movePath(path, trash); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	tx.record("remove "+path, func() error {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		return movePath(trash, path)
+	})
+	return nil
+}
+
+// OnCommit registers fn to run when the transaction commits, for cleanup
+// that can't be undone (e.g., deleting caches)
+func (tx *Transaction) OnCommit(fn func()) {
+	tx.onCommit = append(tx.onCommit, fn)
+}
+
+// Commit keeps all changes and ends the transaction. Journal installed.json
+// with Modified before recording the result in it (which also checks for
+// Ctrl-C), and Commit after it. It does nothing once the transaction ended.
+func (tx *Transaction) Commit() {
+	if !tx.end() {
+		return
+	}
+	if tx.trashDir != "" {
+		os.RemoveAll(tx.trashDir)
+	}
+	for _, fn := range tx.onCommit {
+		fn()
+	}
+}
+
+// Rollback undoes every journaled change in reverse order and ends the
+// transaction. It does nothing after Commit, so it can be deferred.
+func (tx *Transaction) Rollback() error {
+	if !tx.end() {
+		return nil
+	}
+
+	var errs []error
+	for i := len(tx.journal) - 1; i >= 0; i-- {
+		step := tx.journal[i]
+		if err := step.undo(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to undo %s: %w", step.desc, err))
+		}
+	}
+	tx.journal = nil
+
+	// Keep removed files that couldn't be restored
+	if len(errs) == 0 && tx.trashDir != "" {
+		os.RemoveAll(tx.trashDir)
+	}
+	return errors.Join(errs...)
+}
+
+// end stops catching Ctrl-C. It returns false if the transaction had
+// already ended.
+func (tx *Transaction) end() bool {
+	tx.mu.Lock()
+	done := tx.done
+	tx.done = true
+	tx.mu.Unlock()
+	if done {
+		return false
+	}
+	signal.Stop(tx.signals)
+	close(tx.signals)
+	return true
+}
+
+func (tx *Transaction) record(desc string, undo func() error) {
+	tx.journal = append(tx.journal, txStep{desc: desc, undo: undo})
+}
+
+// trashPath returns a new path in the transaction's trash directory
+func (tx *Transaction) trashPath() (string, error) {
+	if tx.trashDir == "" {
+		if err := config.EnsureDir(config.CodexMarketDir()); err != nil {
+			return "", err
+		}
+		dir, err := os.MkdirTemp(config.CodexMarketDir(), ".tx-*")
+		if err != nil {
+			return "", fmt.Errorf("failed to create transaction directory: %w", err)
+		}
+		tx.trashDir = dir
+	}
+	return filepath.Join(tx.trashDir, fmt.Sprintf("%d", len(tx.journal))), nil
+}
+
+// movePath renames src to dst, copying when they are on different filesystems
+func movePath(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		err = CopyDir(src, dst)
+	} else {
+		err = CopyFile(src, dst)
+	}
+	if err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
@@ -0,0 +1,48 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTransactionEndsOnce(t *testing.T) {
+	tests := []struct {
+		name    string
+		end     func(tx *Transaction)
+		wantDir bool // whether the created directory is kept
+	}{
+		{"commit twice", func(tx *Transaction) { tx.Commit(); tx.Commit() }, true},
+		{"rollback after commit", func(tx *Transaction) { tx.Commit(); tx.Rollback() }, true},
+		{"commit after rollback", func(tx *Transaction) { tx.Rollback(); tx.Commit() }, false},
+		{"rollback twice", func(tx *Transaction) { tx.Rollback(); tx.Rollback() }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "a", "b")
+			tx := BeginTransaction()
+			if err := tx.Created(dir); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			committed := 0
+			tx.OnCommit(func() { committed++ })
+
+			tt.end(tx)
+
+			if _, err := os.Stat(dir); (err == nil) != tt.wantDir {
+				t.Errorf("directory exists = %v, want %v", err == nil, tt.wantDir)
+			}
+			// Rollback removes the parent directories it created as well
+			if _, err := os.Stat(filepath.Dir(dir)); !tt.wantDir && err == nil {
+				t.Error("created parent directory was kept")
+			}
+			if tt.wantDir && committed != 1 || !tt.wantDir && committed != 0 {
+				t.Errorf("commit hooks ran %d times", committed)
+			}
+		})
+	}
+}
@@ -1,21 +1,101 @@
 package plugin
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 // PluginManifest represents the .claude-plugin/plugin.json structure
 type PluginManifest struct {
-	Name        string   `json:"name"`
-	Version     string   `json:"version,omitempty"`
-	Description string   `json:"description,omitempty"`
-	Author      *Author  `json:"author,omitempty"`
-	Homepage    string   `json:"homepage,omitempty"`
-	Repository  string   `json:"repository,omitempty"`
-	License     string   `json:"license,omitempty"`
-	Keywords    []string `json:"keywords,omitempty"`
-	Commands    any      `json:"commands,omitempty"`   // string or []string
-	Agents      string   `json:"agents,omitempty"`
-	Skills      string   `json:"skills,omitempty"`
-	Hooks       string   `json:"hooks,omitempty"`
-	MCPServers  string   `json:"mcpServers,omitempty"`
-	LSPServers  string   `json:"lspServers,omitempty"`
+	Name         string       `json:"name"`
+	Version      string       `json:"version,omitempty"`
+	Description  string       `json:"description,omitempty"`
+	Author       *Author      `json:"author,omitempty"`
+	Homepage     string       `json:"homepage,omitempty"`
+	Repository   string       `json:"repository,omitempty"`
+	License      string       `json:"license,omitempty"`
+	Keywords     []string     `json:"keywords,omitempty"`
+	Commands     PathList     `json:"commands,omitempty"`     // string or []string
+	Agents       PathList     `json:"agents,omitempty"`       // string or []string
+	Skills       PathList     `json:"skills,omitempty"`       // string or []string
+	OutputStyles PathList     `json:"outputStyles,omitempty"` // string or []string
+	Hooks        ComponentRef `json:"hooks,omitempty"`        // path(s) or inline object
+	MCPServers   ComponentRef `json:"mcpServers,omitempty"`   // path(s) or inline object
+	LSPServers   ComponentRef `json:"lspServers,omitempty"`   // path(s) or inline object
+}
+
+// PathList is a list of plugin-relative paths that may be written as a
+// single string or as an array of strings in plugin.json
+type PathList []string
+
+// UnmarshalJSON implements custom JSON unmarshaling for PathList
+func (p *PathList) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		if str == "" {
+			*p = nil
+		} else {
+			*p = PathList{str}
+		}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*p = list
+		return nil
+	}
+
+	return fmt.Errorf("invalid path format: expected string or array of strings")
+}
+
+// ComponentRef references a component configuration that may be written as
+// a path, an array of paths, or an inline JSON object in plugin.json
+type ComponentRef struct {
+	Paths  []string        // plugin-relative config file paths
+	Inline json.RawMessage // inline configuration object
+	// EntryInline is the marketplace entry's inline object when it was merged
+	// with plugin.json. It is kept apart so that names declared in both are
+	// reported instead of one silently replacing the other.
+	EntryInline json.RawMessage
+}
+
+// UnmarshalJSON implements custom JSON unmarshaling for ComponentRef
+func (c *ComponentRef) UnmarshalJSON(data []byte) error {
+	var paths PathList
+	if err := json.Unmarshal(data, &paths); err == nil {
+		c.Paths = paths
+		c.Inline = nil
+		return nil
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err == nil {
+		c.Paths = nil
+		c.Inline = append(json.RawMessage(nil), data...)
+		return nil
+	}
+
+	return fmt.Errorf("invalid component format: expected string, array of strings, or object")
+}
+
+// MarshalJSON implements custom JSON marshaling for ComponentRef
+func (c ComponentRef) MarshalJSON() ([]byte, error) {
+	switch {
+	case len(c.Inline) > 0:
+		return c.Inline, nil
+	case len(c.Paths) == 1:
+		return json.Marshal(c.Paths[0])
+	case len(c.Paths) > 1:
+		return json.Marshal(c.Paths)
+	default:
+		return []byte("null"), nil
+	}
+}
+
+// IsEmpty returns true if the reference declares neither paths nor an inline object
+func (c ComponentRef) IsEmpty() bool {
+	return len(c.Paths) == 0 && len(c.Inline) == 0 && len(c.EntryInline) == 0
 }
 
 // Author represents the plugin author information
@@ -38,17 +118,21 @@ type InstalledPluginEntry struct {
 	Version     string           `json:"version"`
 	InstalledAt string           `json:"installedAt"`
 	LastUpdated string           `json:"lastUpdated"`
-	Source      PluginSource     `json:"source"`                // where it was installed from
-	Skills      []SkillEntry     `json:"skills"`                // installed skills with paths
-	Commands    []CommandEntry   `json:"commands,omitempty"`    // installed commands with paths
-	MCPServers  []MCPServerEntry `json:"mcpServers,omitempty"`  // installed MCP servers
+	Source      PluginSource     `json:"source"`               // where it was installed from
+	Skills      []SkillEntry     `json:"skills"`               // installed skills with paths
+	Commands    []CommandEntry   `json:"commands,omitempty"`   // installed commands with paths
+	Agents      []AgentEntry     `json:"agents,omitempty"`     // agents converted to skills
+	MCPServers  []MCPServerEntry `json:"mcpServers,omitempty"` // installed MCP servers
+	Files       []InstalledFile  `json:"files,omitempty"`      // hashes of installed skill, command and agent files
 }
 
 // PluginSource represents the source of an installed plugin
 type PluginSource struct {
-	Marketplace string `json:"marketplace"`        // marketplace name
-	URL         string `json:"url"`                // git URL
-	CachePath   string `json:"cachePath"`          // local cache path for tracking
+	Marketplace string `json:"marketplace"`      // marketplace name
+	URL         string `json:"url"`              // git URL
+	CachePath   string `json:"cachePath"`        // local cache path for tracking
+	Commit      string `json:"commit,omitempty"` // resolved commit of a git plugin source
+	Checksum    string `json:"sha256,omitempty"` // SHA-256 of the installed archive (archive sources)
 }
 
 // SkillEntry represents an installed skill with its path
@@ -59,8 +143,22 @@ type SkillEntry struct {
 
 // CommandEntry represents an installed command with its path
 type CommandEntry struct {
-	Name string `json:"name"` // command name (without .md extension)
-	Path string `json:"path"` // full path to command file (for deletion)
+	Name   string `json:"name"`             // command name (without .md extension)
+	Path   string `json:"path"`             // full path to command file (for deletion)
+	Source string `json:"source,omitempty"` // original Claude command name (e.g., "git:commit")
+}
+
+// AgentEntry represents a Claude agent converted into a Codex skill
+type AgentEntry struct {
+	Name  string `json:"name"`  // agent name from the definition
+	Skill string `json:"skill"` // resolved skill folder name
+	Path  string `json:"path"`  // full path to the generated skill folder (for deletion)
+}
+
+// InstalledFile records the contents of an installed file, to detect local edits
+type InstalledFile struct {
+	Path   string `json:"path"`   // full path to the file
+	SHA256 string `json:"sha256"` // SHA-256 of the contents as installed
 }
 
 // MCPServerEntry represents an installed MCP server
@@ -72,7 +170,7 @@ type MCPServerEntry struct {
 // NewInstalledPlugins creates a new InstalledPlugins instance
 func NewInstalledPlugins() *InstalledPlugins {
 	return &InstalledPlugins{
-		Version: 1,
+		Version: InstalledVersion,
 		Plugins: make(map[string][]InstalledPluginEntry),
 	}
 }
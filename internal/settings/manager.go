@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/egoavara/codex-market/internal/backup"
 	"github.com/egoavara/codex-market/internal/config"
 )
 
@@ -44,7 +45,7 @@ func Save(path string, settings *ClaudeSettings) error {
 		return err
 	}
 
-	return os.WriteFile(path, data, 0644)
+	return backup.WriteFile(path, data, 0644)
 }
 
 // EnablePlugin enables a plugin in the settings
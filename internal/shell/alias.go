@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"strings"
+
+	"github.com/egoavara/codex-market/internal/backup"
 )
 
 const (
@@ -45,17 +47,15 @@ func HasCodexAlias(configPath string) (bool, error) {
 
 // AddCodexAlias adds the codex alias to the shell config file
 func AddCodexAlias(configPath string) error {
-	// Open file in append mode, create if not exists
-	file, err := os.OpenFile(configPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to open config file: %w", err)
+	data, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read config file: %w", err)
 	}
-	defer file.Close()
 
 	// Add newline, marker, and alias
-	content := fmt.Sprintf("\n%s\n%s\n", AliasMarker, AliasLine)
+	content := string(data) + fmt.Sprintf("\n%s\n%s\n", AliasMarker, AliasLine)
 
-	if _, err := file.WriteString(content); err != nil {
+	if err := backup.WriteFile(configPath, []byte(content), 0644); err != nil {
 		return fmt.Errorf("failed to write alias: %w", err)
 	}
 
@@ -90,7 +90,7 @@ func RemoveCodexAlias(configPath string) error {
 
 	// Write back
 	newContent := strings.Join(newLines, "\n")
-	if err := os.WriteFile(configPath, []byte(newContent), 0644); err != nil {
+	if err := backup.WriteFile(configPath, []byte(newContent), 0644); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
@@ -2,9 +2,11 @@ package main
 
 import (
 	"embed"
+	"os"
 
 	"github.com/egoavara/codex-market/cmd"
 	"github.com/egoavara/codex-market/internal/config"
+	"github.com/egoavara/codex-market/internal/git"
 	"github.com/egoavara/codex-market/internal/i18n"
 	"github.com/jeandeaual/go-locale"
 )
@@ -13,6 +15,11 @@ import (
 var localeFS embed.FS
 
 func main() {
+	// Answer git's credential prompt when started as GIT_ASKPASS
+	if git.RunAskpass(os.Args[1:]) {
+		return
+	}
+
 	// i18n 초기화
 	lang := getLocale()
 	i18n.Init(localeFS, lang)